@@ -0,0 +1,198 @@
+package mistral
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultAgentMaxIterations     = 10
+	DefaultAgentMaxRepairAttempts = 2
+)
+
+var (
+	// ErrAgentMaxIterations is returned by RunTools when the model keeps calling tools after the last iteration.
+	ErrAgentMaxIterations = errors.New("agent reached the maximum number of iterations")
+	// ErrAgentTokenBudget is returned by RunTools when the conversation used more tokens than allowed.
+	ErrAgentTokenBudget = errors.New("agent exceeded its token budget")
+	// ErrAgentRepairAttempts is returned by RunTools when the model keeps calling tools with invalid arguments.
+	ErrAgentRepairAttempts = errors.New("agent exhausted its tool argument repair attempts")
+)
+
+// AgentOptions configures RunTools.
+type AgentOptions struct {
+	MaxIterations    int           // Maximum number of chat requests. Defaults to DefaultAgentMaxIterations.
+	MaxTotalTokens   int           // Stop once the total tokens used across all requests reach this value. Zero means no limit.
+	ToolTimeout      time.Duration // Timeout for tools registered without their own timeout. Zero means no timeout.
+	MaxParallelTools int           // Maximum number of tool calls executed at once. Zero runs every call of a turn in parallel.
+
+	// MaxRepairAttempts bounds how many turns with invalid tool arguments are answered with the validation error so
+	// the model can correct itself. Defaults to DefaultAgentMaxRepairAttempts; a negative value allows none.
+	MaxRepairAttempts int
+}
+
+// AgentStep records one iteration of RunTools: the model response and the tool calls executed because of it.
+type AgentStep struct {
+	Iteration   int                     `json:"iteration"`
+	StartedAt   time.Time               `json:"started_at"`
+	Duration    time.Duration           `json:"duration"`
+	Response    *ChatCompletionResponse `json:"response"`
+	ToolCalls   []ToolCall              `json:"tool_calls,omitempty"`
+	ToolResults []ChatMessage           `json:"tool_results,omitempty"`
+	ToolErrors  []string                `json:"tool_errors,omitempty"` // The error of each tool call, empty for calls that succeeded.
+}
+
+// AgentResult is the outcome of RunTools.
+type AgentResult struct {
+	Messages []ChatMessage `json:"messages"` // The full conversation, including the input messages and the final answer.
+	Final    ChatMessage   `json:"final"`    // The final answer of the model; empty if the loop stopped early.
+	Steps    []AgentStep   `json:"steps"`    // A transcript of every iteration.
+	Usage    UsageInfo     `json:"usage"`    // The usage summed over every request.
+}
+
+// RunTools runs a tool-calling loop: it sends the conversation to the model with the registry's tools, executes the
+// tool calls the model makes, sends the results back and repeats until the model answers without calling a tool.
+// Tool calls of the same turn are executed in parallel. Calls whose arguments do not match the tool's schema are
+// answered with the validation error so the model can repair them. When the loop stops early because of a guard in
+// opts the partial result is returned along with ErrAgentMaxIterations, ErrAgentTokenBudget or
+// ErrAgentRepairAttempts.
+func (c *MistralClient) RunTools(ctx context.Context, model string, messages []ChatMessage, registry *ToolRegistry, params *ChatRequestParams, opts *AgentOptions) (*AgentResult, error) {
+	if registry == nil {
+		return nil, errors.New("RunTools requires a tool registry")
+	}
+	if params == nil {
+		params = &DefaultChatRequestParams
+	}
+	if opts == nil {
+		opts = &AgentOptions{}
+	}
+	maxIterations := opts.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = DefaultAgentMaxIterations
+	}
+	maxRepairs := opts.MaxRepairAttempts
+	if maxRepairs == 0 {
+		maxRepairs = DefaultAgentMaxRepairAttempts
+	}
+	repairs := 0
+
+	requestParams := *params
+	if requestParams.Tools == nil {
+		requestParams.Tools = registry.Tools()
+	}
+	if requestParams.ToolChoice == "" {
+		requestParams.ToolChoice = ToolChoiceAuto
+	}
+
+	result := &AgentResult{
+		Messages: append([]ChatMessage(nil), messages...),
+	}
+
+	for iteration := 1; iteration <= maxIterations; iteration++ {
+		step := AgentStep{Iteration: iteration, StartedAt: time.Now()}
+
+		res, err := c.ChatContext(ctx, model, result.Messages, &requestParams)
+		if err != nil {
+			return result, err
+		}
+		if len(res.Choices) == 0 {
+			return result, fmt.Errorf("agent iteration %d: response has no choices", iteration)
+		}
+		step.Response = res
+		result.Usage.PromptTokens += res.Usage.PromptTokens
+		result.Usage.CompletionTokens += res.Usage.CompletionTokens
+		result.Usage.TotalTokens += res.Usage.TotalTokens
+
+		message := res.Choices[0].Message
+		result.Messages = append(result.Messages, message)
+
+		if len(message.ToolCalls) == 0 {
+			step.Duration = time.Since(step.StartedAt)
+			result.Steps = append(result.Steps, step)
+			result.Final = message
+			return result, nil
+		}
+
+		if opts.MaxTotalTokens > 0 && result.Usage.TotalTokens >= opts.MaxTotalTokens {
+			step.Duration = time.Since(step.StartedAt)
+			result.Steps = append(result.Steps, step)
+			return result, fmt.Errorf("%w: used %d of %d tokens", ErrAgentTokenBudget, result.Usage.TotalTokens, opts.MaxTotalTokens)
+		}
+
+		step.ToolCalls = message.ToolCalls
+		toolResults, toolErrs := executeToolCalls(ctx, registry, message.ToolCalls, opts)
+		step.ToolResults = toolResults
+		step.ToolErrors = toolErrorStrings(toolErrs)
+		step.Duration = time.Since(step.StartedAt)
+		result.Steps = append(result.Steps, step)
+		result.Messages = append(result.Messages, toolResults...)
+
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if validationErr := firstValidationError(toolErrs); validationErr != nil {
+			repairs++
+			if repairs > maxRepairs {
+				return result, fmt.Errorf("%w: %v", ErrAgentRepairAttempts, validationErr)
+			}
+		}
+	}
+
+	return result, fmt.Errorf("%w (%d)", ErrAgentMaxIterations, maxIterations)
+}
+
+// executeToolCalls runs the tool calls of one turn, at most opts.MaxParallelTools at a time, and returns their
+// result messages and errors in call order.
+func executeToolCalls(ctx context.Context, registry *ToolRegistry, calls []ToolCall, opts *AgentOptions) ([]ChatMessage, []error) {
+	results := make([]ChatMessage, len(calls))
+	errs := make([]error, len(calls))
+
+	parallel := opts.MaxParallelTools
+	if parallel <= 0 || parallel > len(calls) {
+		parallel = len(calls)
+	}
+	sem := make(chan struct{}, parallel)
+
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, call ToolCall) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i], errs[i] = registry.execute(ctx, call, opts.ToolTimeout)
+		}(i, call)
+	}
+	wg.Wait()
+
+	return results, errs
+}
+
+// toolErrorStrings converts the errors of a turn's tool calls for the transcript, returning nil if none failed.
+func toolErrorStrings(errs []error) []string {
+	var strs []string
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if strs == nil {
+			strs = make([]string, len(errs))
+		}
+		strs[i] = err.Error()
+	}
+	return strs
+}
+
+func firstValidationError(errs []error) error {
+	for _, err := range errs {
+		var validationErr *SchemaValidationError
+		if errors.As(err, &validationErr) {
+			return err
+		}
+	}
+	return nil
+}
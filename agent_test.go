@@ -0,0 +1,116 @@
+package mistral
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func toolCallResponse(calls ...ToolCall) ChatCompletionResponse {
+	return ChatCompletionResponse{
+		ID:      "tool",
+		Choices: []ChatCompletionResponseChoice{{Message: ChatMessage{Role: RoleAssistant, ToolCalls: calls}, FinishReason: FinishReasonToolCalls}},
+		Usage:   UsageInfo{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}
+}
+
+func answerResponse(content string) ChatCompletionResponse {
+	return ChatCompletionResponse{
+		ID:      "answer",
+		Choices: []ChatCompletionResponseChoice{{Message: AssistantMessage(content), FinishReason: FinishReasonStop}},
+		Usage:   UsageInfo{PromptTokens: 20, CompletionTokens: 5, TotalTokens: 25},
+	}
+}
+
+func TestRunTools(t *testing.T) {
+	srv := newScriptedServer(t,
+		toolCallResponse(
+			ToolCall{Id: "a", Type: ToolTypeFunction, Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Dallas"}`}},
+			ToolCall{Id: "b", Type: ToolTypeFunction, Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Austin"}`}},
+		),
+		answerResponse("Both are sunny."),
+	)
+
+	// Both calls must be running at the same time for either to finish.
+	var started sync.WaitGroup
+	started.Add(2)
+	registry := NewToolRegistry()
+	assert.NoError(t, registry.Register(Function{Name: "get_weather"}, func(ctx context.Context, call ToolCall) (any, error) {
+		started.Done()
+		started.Wait()
+		return "sunny in " + call.Function.Arguments, nil
+	}))
+
+	client := srv.Client()
+	res, err := client.RunTools(context.Background(), ModelMistralSmallLatest, []ChatMessage{UserMessage("Weather in Dallas and Austin?")}, registry, nil, &AgentOptions{ToolTimeout: time.Second})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Both are sunny.", res.Final.Content)
+	assert.Len(t, res.Messages, 5)
+	assert.Len(t, res.Steps, 2)
+	assert.Equal(t, []string(nil), res.Steps[0].ToolErrors)
+	assert.Equal(t, "a", res.Steps[0].ToolResults[0].ToolCallId)
+	assert.Equal(t, `sunny in {"city":"Dallas"}`, res.Steps[0].ToolResults[0].Content)
+	assert.Equal(t, "b", res.Steps[0].ToolResults[1].ToolCallId)
+	assert.Equal(t, UsageInfo{PromptTokens: 30, CompletionTokens: 10, TotalTokens: 40}, res.Usage)
+
+	assert.Len(t, srv.Requests(), 2)
+	assert.Len(t, srv.Requests()[1].Messages(), 4)
+	assert.Equal(t, RoleTool, srv.Requests()[1].Messages()[2].Role)
+}
+
+func TestRunToolsGuards(t *testing.T) {
+	call := ToolCall{Id: "a", Type: ToolTypeFunction, Function: FunctionCall{Name: "noop", Arguments: `{}`}}
+	registry := NewToolRegistry()
+	assert.NoError(t, registry.Register(Function{Name: "noop"}, func(ctx context.Context, call ToolCall) (any, error) {
+		return "ok", nil
+	}))
+	messages := []ChatMessage{UserMessage("loop forever")}
+
+	srv := newScriptedServer(t, toolCallResponse(call), toolCallResponse(call), toolCallResponse(call))
+	client := srv.Client()
+	res, err := client.RunTools(context.Background(), ModelMistralSmallLatest, messages, registry, nil, &AgentOptions{MaxIterations: 2})
+	assert.ErrorIs(t, err, ErrAgentMaxIterations)
+	assert.Len(t, res.Steps, 2)
+
+	srv = newScriptedServer(t, toolCallResponse(call), toolCallResponse(call), toolCallResponse(call))
+	client = srv.Client()
+	res, err = client.RunTools(context.Background(), ModelMistralSmallLatest, messages, registry, nil, &AgentOptions{MaxTotalTokens: 20})
+	assert.ErrorIs(t, err, ErrAgentTokenBudget)
+	assert.Len(t, res.Steps, 2)
+	assert.Equal(t, 30, res.Usage.TotalTokens)
+
+	res, err = client.RunTools(context.Background(), ModelMistralSmallLatest, messages, nil, nil, nil)
+	assert.EqualError(t, err, "RunTools requires a tool registry")
+	assert.Nil(t, res)
+}
+
+func TestRunToolsRepairsInvalidArguments(t *testing.T) {
+	badCall := ToolCall{Id: "a", Type: ToolTypeFunction, Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Dallas"}`}}
+	goodCall := ToolCall{Id: "b", Type: ToolTypeFunction, Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Dallas","state":"TX"}`}}
+
+	registry := NewToolRegistry()
+	var calls []weatherArgs
+	assert.NoError(t, RegisterFunction(registry, "get_weather", "Retrieve the weather", func(ctx context.Context, args weatherArgs) (any, error) {
+		calls = append(calls, args)
+		return "sunny", nil
+	}))
+	messages := []ChatMessage{UserMessage("Weather in Dallas?")}
+
+	srv := newScriptedServer(t, toolCallResponse(badCall), toolCallResponse(goodCall), answerResponse("Sunny."))
+	client := srv.Client()
+	res, err := client.RunTools(context.Background(), ModelMistralSmallLatest, messages, registry, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Sunny.", res.Final.Content)
+	assert.Equal(t, []weatherArgs{{City: "Dallas", State: "TX"}}, calls)
+	assert.Contains(t, res.Steps[0].ToolErrors[0], "$.state: required property is missing")
+	assert.Contains(t, srv.Requests()[1].Messages()[2].Content, "Fix the arguments")
+
+	srv = newScriptedServer(t, toolCallResponse(badCall), toolCallResponse(badCall), toolCallResponse(goodCall))
+	client = srv.Client()
+	_, err = client.RunTools(context.Background(), ModelMistralSmallLatest, messages, registry, nil, &AgentOptions{MaxRepairAttempts: 1})
+	assert.ErrorIs(t, err, ErrAgentRepairAttempts)
+}
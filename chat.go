@@ -1,8 +1,7 @@
 package mistral
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,23 +9,40 @@ import (
 )
 
 // ChatRequestParams represents the parameters for the Chat/ChatStream method of MistralClient.
+// Pointer fields are optional: nil leaves the parameter unset so the API default applies. Use Ptr to set them.
 type ChatRequestParams struct {
-	Temperature    float64        `json:"temperature"` // The temperature to use for sampling. Higher values like 0.8 will make the output more random, while lower values like 0.2 will make it more focused and deterministic. We generally recommend altering this or TopP but not both.
-	TopP           float64        `json:"top_p"`       // An alternative to sampling with temperature, called nucleus sampling, where the model considers the results of the tokens with top_p probability mass. So 0.1 means only the tokens comprising the top 10% probability mass are considered. We generally recommend altering this or Temperature but not both.
-	RandomSeed     int            `json:"random_seed"`
-	MaxTokens      int            `json:"max_tokens"`
-	SafePrompt     bool           `json:"safe_prompt"` // Adds a Mistral defined safety message to the system prompt to enforce guardrailing
-	Tools          []Tool         `json:"tools"`
-	ToolChoice     string         `json:"tool_choice"`
-	ResponseFormat ResponseFormat `json:"response_format"`
+	Temperature       *float64          `json:"temperature,omitempty"` // The temperature to use for sampling. Higher values like 0.8 will make the output more random, while lower values like 0.2 will make it more focused and deterministic. We generally recommend altering this or TopP but not both.
+	TopP              *float64          `json:"top_p,omitempty"`       // An alternative to sampling with temperature, called nucleus sampling, where the model considers the results of the tokens with top_p probability mass. So 0.1 means only the tokens comprising the top 10% probability mass are considered. We generally recommend altering this or Temperature but not both.
+	RandomSeed        *int              `json:"random_seed,omitempty"`
+	MaxTokens         *int              `json:"max_tokens,omitempty"`
+	SafePrompt        bool              `json:"safe_prompt"` // Adds a Mistral defined safety message to the system prompt to enforce guardrailing
+	Tools             []Tool            `json:"tools"`
+	ToolChoice        string            `json:"tool_choice"`
+	ResponseFormat    ResponseFormat    `json:"response_format"`
+	Stop              []string          `json:"stop,omitempty"`                // Stop generation when any of these sequences is generated.
+	PresencePenalty   *float64          `json:"presence_penalty,omitempty"`    // Penalizes tokens that already appear in the text, encouraging new topics.
+	FrequencyPenalty  *float64          `json:"frequency_penalty,omitempty"`   // Penalizes tokens proportionally to how often they already appear in the text.
+	N                 *int              `json:"n,omitempty"`                   // Number of completions (choices) to return.
+	Prediction        *Prediction       `json:"prediction,omitempty"`          // Expected content of the response, used to speed up generation when most of it is known.
+	ParallelToolCalls *bool             `json:"parallel_tool_calls,omitempty"` // Whether the model may call several tools in a single turn.
+	PromptMode        PromptMode        `json:"prompt_mode,omitempty"`         // Selects a system prompt preset for reasoning models.
+	JSONSchema        *JSONSchemaFormat `json:"json_schema,omitempty"`         // The schema the response must match. Setting it implies ResponseFormatJsonSchema.
 }
 
+// DefaultChatRequestParams leaves every optional parameter unset so the API defaults apply.
 var DefaultChatRequestParams = ChatRequestParams{
-	Temperature: 1,
-	TopP:        1,
-	RandomSeed:  42069,
-	MaxTokens:   4000,
-	SafePrompt:  false,
+	SafePrompt: false,
+}
+
+// Prediction is the expected content of a response. Tokens matching the prediction are generated faster.
+type Prediction struct {
+	Type    PredictionType `json:"type"`
+	Content string         `json:"content"`
+}
+
+// Ptr returns a pointer to v. It is a convenience for setting optional parameters such as ChatRequestParams.Temperature.
+func Ptr[T any](v T) *T {
+	return &v
 }
 
 // ChatCompletionResponseChoice represents a choice in the chat completion response.
@@ -61,7 +77,7 @@ type ChatCompletionStreamResponse struct {
 	Created int                                  `json:"created,omitempty"`
 	Object  string                               `json:"object,omitempty"`
 	Usage   UsageInfo                            `json:"usage,omitempty"`
-	Error   error                                `json:"error,omitempty"`
+	Error   error                                `json:"-"` // Set on the final value sent by ChatStream when the stream fails.
 }
 
 // UsageInfo represents the usage information of a response.
@@ -71,32 +87,25 @@ type UsageInfo struct {
 	CompletionTokens int `json:"completion_tokens,omitempty"`
 }
 
+// Chat sends a chat request and returns the completion response.
 func (c *MistralClient) Chat(model string, messages []ChatMessage, params *ChatRequestParams) (*ChatCompletionResponse, error) {
+	return c.ChatContext(context.Background(), model, messages, params)
+}
+
+// ChatContext is like Chat but the request, including any retries, is bound to the given context.
+func (c *MistralClient) ChatContext(ctx context.Context, model string, messages []ChatMessage, params *ChatRequestParams) (*ChatCompletionResponse, error) {
 	if params == nil {
 		params = &DefaultChatRequestParams
 	}
-
-	requestData := map[string]interface{}{
-		"model":       model,
-		"messages":    messages,
-		"temperature": params.Temperature,
-		"max_tokens":  params.MaxTokens,
-		"top_p":       params.TopP,
-		"random_seed": params.RandomSeed,
-		"safe_prompt": params.SafePrompt,
+	if c.validateToolResults {
+		if err := ValidateToolResults(messages); err != nil {
+			return nil, err
+		}
 	}
 
-	if params.Tools != nil {
-		requestData["tools"] = params.Tools
-	}
-	if params.ToolChoice != "" {
-		requestData["tool_choice"] = params.ToolChoice
-	}
-	if params.ResponseFormat != "" {
-		requestData["response_format"] = map[string]any{"type": params.ResponseFormat}
-	}
+	requestData := chatRequestData(model, messages, params, false)
 
-	response, err := c.request(http.MethodPost, requestData, "v1/chat/completions", false, nil)
+	response, err := c.request(ctx, http.MethodPost, requestData, "v1/chat/completions", false, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -117,94 +126,105 @@ func (c *MistralClient) Chat(model string, messages []ChatMessage, params *ChatR
 
 // ChatStream sends a chat message and returns a channel to receive streaming responses.
 func (c *MistralClient) ChatStream(model string, messages []ChatMessage, params *ChatRequestParams) (<-chan ChatCompletionStreamResponse, error) {
+	return c.ChatStreamContext(context.Background(), model, messages, params)
+}
+
+// ChatStreamContext is like ChatStream but the request and the streaming goroutine are bound to the given context.
+// Cancelling the context closes the response body and the returned channel, so consumers that stop reading early
+// should cancel it to release the connection.
+func (c *MistralClient) ChatStreamContext(ctx context.Context, model string, messages []ChatMessage, params *ChatRequestParams) (<-chan ChatCompletionStreamResponse, error) {
+	stream, err := c.OpenChatStream(ctx, model, messages, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return streamToChannel(ctx, stream), nil
+}
+
+// OpenChatStream sends a chat message and returns a ChatStreamReader to iterate over the streamed responses.
+// The caller must Close the reader once done with it.
+func (c *MistralClient) OpenChatStream(ctx context.Context, model string, messages []ChatMessage, params *ChatRequestParams) (*ChatStreamReader, error) {
 	if params == nil {
 		params = &DefaultChatRequestParams
 	}
+	if c.validateToolResults {
+		if err := ValidateToolResults(messages); err != nil {
+			return nil, err
+		}
+	}
 
-	responseChannel := make(chan ChatCompletionStreamResponse)
+	requestData := chatRequestData(model, messages, params, true)
 
+	response, err := c.request(ctx, http.MethodPost, requestData, "v1/chat/completions", true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, ok := response.(io.ReadCloser)
+	if !ok {
+		return nil, fmt.Errorf("invalid response type: %T", response)
+	}
+
+	return newChatStreamReader(respBody), nil
+}
+
+// chatRequestData builds the request body for the chat completion endpoint, leaving out optional parameters that are unset.
+func chatRequestData(model string, messages []ChatMessage, params *ChatRequestParams, stream bool) map[string]interface{} {
 	requestData := map[string]interface{}{
 		"model":       model,
 		"messages":    messages,
-		"temperature": params.Temperature,
-		"max_tokens":  params.MaxTokens,
-		"top_p":       params.TopP,
-		"random_seed": params.RandomSeed,
 		"safe_prompt": params.SafePrompt,
-		"stream":      true,
 	}
 
+	if params.Temperature != nil {
+		requestData["temperature"] = *params.Temperature
+	}
+	if params.TopP != nil {
+		requestData["top_p"] = *params.TopP
+	}
+	if params.RandomSeed != nil {
+		requestData["random_seed"] = *params.RandomSeed
+	}
+	if params.MaxTokens != nil {
+		requestData["max_tokens"] = *params.MaxTokens
+	}
 	if params.Tools != nil {
 		requestData["tools"] = params.Tools
 	}
 	if params.ToolChoice != "" {
 		requestData["tool_choice"] = params.ToolChoice
 	}
-	if params.ResponseFormat != "" {
+	if params.JSONSchema != nil {
+		requestData["response_format"] = map[string]any{"type": ResponseFormatJsonSchema, "json_schema": params.JSONSchema}
+	} else if params.ResponseFormat != "" {
 		requestData["response_format"] = map[string]any{"type": params.ResponseFormat}
 	}
-
-	response, err := c.request(http.MethodPost, requestData, "v1/chat/completions", true, nil)
-	if err != nil {
-		return nil, err
+	if params.Stop != nil {
+		requestData["stop"] = params.Stop
 	}
-
-	respBody, ok := response.(io.ReadCloser)
-	if !ok {
-		return nil, fmt.Errorf("invalid response type: %T", response)
+	if params.PresencePenalty != nil {
+		requestData["presence_penalty"] = *params.PresencePenalty
+	}
+	if params.FrequencyPenalty != nil {
+		requestData["frequency_penalty"] = *params.FrequencyPenalty
+	}
+	if params.N != nil {
+		requestData["n"] = *params.N
+	}
+	if params.Prediction != nil {
+		requestData["prediction"] = params.Prediction
+	}
+	if params.ParallelToolCalls != nil {
+		requestData["parallel_tool_calls"] = *params.ParallelToolCalls
+	}
+	if params.PromptMode != "" {
+		requestData["prompt_mode"] = params.PromptMode
+	}
+	if stream {
+		requestData["stream"] = true
 	}
 
-	// Execute the HTTP request in a separate goroutine.
-	go func() {
-		defer close(responseChannel)
-		defer respBody.Close()
-
-		// Assuming ChatCompletionStreamResponse is already defined in your Go code.
-		// Assuming responseChannel is a channel of ChatCompletionStreamResponse.
-
-		// Create a buffered reader to read the stream line by line.
-		reader := bufio.NewReader(respBody)
-
-		for {
-			// Read a line from the buffered reader.
-			line, err := reader.ReadBytes('\n')
-			if err == io.EOF {
-				break // End of stream.
-			} else if err != nil {
-				responseChannel <- ChatCompletionStreamResponse{Error: fmt.Errorf("error reading stream response: %w", err)}
-				return
-			}
-
-			// Skip empty lines.
-			if bytes.Equal(line, []byte("\n")) {
-				continue
-			}
-
-			// Check if the line starts with "data: ".
-			if bytes.HasPrefix(line, []byte("data: ")) {
-				// Trim the prefix and any leading or trailing whitespace.
-				jsonLine := bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data: ")))
-
-				// Check for the special "[DONE]" message.
-				if bytes.Equal(jsonLine, []byte("[DONE]")) {
-					break
-				}
-
-				// Decode the JSON object from the line.
-				var streamResponse ChatCompletionStreamResponse
-				if err := json.Unmarshal(jsonLine, &streamResponse); err != nil {
-					responseChannel <- ChatCompletionStreamResponse{Error: fmt.Errorf("error decoding stream response: %w", err)}
-					continue
-				}
-
-				// Send the decoded response to the channel.
-				responseChannel <- streamResponse
-			}
-		}
-	}()
-
-	// Return the response channel.
-	return responseChannel, nil
+	return requestData
 }
 
 // mapToStruct is a helper function to convert a map to a struct.
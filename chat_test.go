@@ -7,10 +7,11 @@ import (
 )
 
 func TestChat(t *testing.T) {
+	skipWithoutAPIKey(t, "MISTRAL_API_KEY")
 	client := NewMistralClientDefault("")
 	params := DefaultChatRequestParams
-	params.MaxTokens = 10
-	params.Temperature = 0
+	params.MaxTokens = Ptr(10)
+	params.Temperature = Ptr(0.0)
 	res, err := client.Chat(
 		ModelMistralTiny,
 		[]ChatMessage{
@@ -31,10 +32,11 @@ func TestChat(t *testing.T) {
 }
 
 func TestChatCodestral(t *testing.T) {
+	skipWithoutAPIKey(t, "CODESTRAL_API_KEY")
 	client := NewCodestralClientDefault("")
 	params := DefaultChatRequestParams
-	params.MaxTokens = 10
-	params.Temperature = 0
+	params.MaxTokens = Ptr(10)
+	params.Temperature = Ptr(0.0)
 	res, err := client.Chat(
 		ModelCodestralLatest,
 		[]ChatMessage{
@@ -55,9 +57,10 @@ func TestChatCodestral(t *testing.T) {
 }
 
 func TestChatFunctionCall(t *testing.T) {
+	skipWithoutAPIKey(t, "MISTRAL_API_KEY")
 	client := NewMistralClientDefault("")
 	params := DefaultChatRequestParams
-	params.Temperature = 0
+	params.Temperature = Ptr(0.0)
 	params.Tools = []Tool{
 		{
 			Type: ToolTypeFunction,
@@ -112,9 +115,10 @@ func TestChatFunctionCall(t *testing.T) {
 }
 
 func TestChatFunctionCall2(t *testing.T) {
+	skipWithoutAPIKey(t, "MISTRAL_API_KEY")
 	client := NewMistralClientDefault("")
 	params := DefaultChatRequestParams
-	params.Temperature = 0
+	params.Temperature = Ptr(0.0)
 	params.Tools = []Tool{
 		{
 			Type: ToolTypeFunction,
@@ -148,6 +152,16 @@ func TestChatFunctionCall2(t *testing.T) {
 		},
 	}
 	params.ToolChoice = ToolChoiceAuto
+	toolCall := ToolCall{
+		Id:   "aaaaaaaaa",
+		Type: ToolTypeFunction,
+		Function: FunctionCall{
+			Name:      "get_weather",
+			Arguments: `{"city": "Dallas", "state": "TX"}`,
+		},
+	}
+	toolResult, err := ToolResultMessage(toolCall, map[string]interface{}{"temperature": 82, "sky": "clear", "precipitation": 0})
+	assert.NoError(t, err)
 	res, err := client.Chat(
 		ModelMistralSmallLatest,
 		[]ChatMessage{
@@ -156,22 +170,10 @@ func TestChatFunctionCall2(t *testing.T) {
 				Content: "What's the weather like in Dallas",
 			},
 			{
-				Role: RoleAssistant,
-				ToolCalls: []ToolCall{
-					{
-						Id:   "aaaaaaaaa",
-						Type: ToolTypeFunction,
-						Function: FunctionCall{
-							Name:      "get_weather",
-							Arguments: `{"city": "Dallas", "state": "TX"}`,
-						},
-					},
-				},
-			},
-			{
-				Role:    RoleTool,
-				Content: `{"temperature": 82, "sky": "clear", "precipitation": 0}`,
+				Role:      RoleAssistant,
+				ToolCalls: []ToolCall{toolCall},
 			},
+			toolResult,
 		},
 		&params,
 	)
@@ -186,9 +188,10 @@ func TestChatFunctionCall2(t *testing.T) {
 }
 
 func TestChatJsonMode(t *testing.T) {
+	skipWithoutAPIKey(t, "MISTRAL_API_KEY")
 	client := NewMistralClientDefault("")
 	params := DefaultChatRequestParams
-	params.Temperature = 0
+	params.Temperature = Ptr(0.0)
 	params.ResponseFormat = ResponseFormatJsonObject
 	res, err := client.Chat(
 		ModelOpenMixtral8x22b,
@@ -215,10 +218,11 @@ func TestChatJsonMode(t *testing.T) {
 }
 
 func TestChatStream(t *testing.T) {
+	skipWithoutAPIKey(t, "MISTRAL_API_KEY")
 	client := NewMistralClientDefault("")
 	params := DefaultChatRequestParams
-	params.MaxTokens = 50
-	params.Temperature = 0
+	params.MaxTokens = Ptr(50)
+	params.Temperature = Ptr(0.0)
 	resChan, err := client.ChatStream(
 		ModelMistralTiny,
 		[]ChatMessage{
@@ -252,9 +256,10 @@ func TestChatStream(t *testing.T) {
 }
 
 func TestChatStreamFunctionCall(t *testing.T) {
+	skipWithoutAPIKey(t, "MISTRAL_API_KEY")
 	client := NewMistralClientDefault("")
 	params := DefaultChatRequestParams
-	params.Temperature = 0
+	params.Temperature = Ptr(0.0)
 	params.Tools = []Tool{
 		{
 			Type: ToolTypeFunction,
@@ -329,9 +334,10 @@ func TestChatStreamFunctionCall(t *testing.T) {
 }
 
 func TestChatStreamJsonMode(t *testing.T) {
+	skipWithoutAPIKey(t, "MISTRAL_API_KEY")
 	client := NewMistralClientDefault("")
 	params := DefaultChatRequestParams
-	params.Temperature = 0
+	params.Temperature = Ptr(0.0)
 	params.ResponseFormat = ResponseFormatJsonObject
 	resChan, err := client.ChatStream(
 		ModelOpenMixtral8x22b,
@@ -375,3 +381,41 @@ func TestChatStreamJsonMode(t *testing.T) {
 	assert.Equal(t, totalOutput, "{\"symbols\": [\"Go\", \"ChatMessage\", \"Any\", \"FunctionCall\", \"ToolCall\", \"ToolResponse\"]}")
 	assert.Nil(t, functionCall)
 }
+
+func TestChatRequestData(t *testing.T) {
+	messages := []ChatMessage{{Role: RoleUser, Content: "hi"}}
+
+	data := chatRequestData(ModelMistralTiny, messages, &DefaultChatRequestParams, false)
+	assert.Equal(t, map[string]interface{}{
+		"model":       ModelMistralTiny,
+		"messages":    messages,
+		"safe_prompt": false,
+	}, data)
+
+	params := ChatRequestParams{
+		Temperature:       Ptr(0.0),
+		TopP:              Ptr(0.9),
+		RandomSeed:        Ptr(0),
+		MaxTokens:         Ptr(100),
+		Stop:              []string{"\n\n"},
+		PresencePenalty:   Ptr(0.5),
+		FrequencyPenalty:  Ptr(-0.5),
+		N:                 Ptr(2),
+		Prediction:        &Prediction{Type: PredictionTypeContent, Content: "func main() {}"},
+		ParallelToolCalls: Ptr(false),
+		PromptMode:        PromptModeReasoning,
+	}
+	data = chatRequestData(ModelMistralTiny, messages, &params, true)
+	assert.Equal(t, 0.0, data["temperature"])
+	assert.Equal(t, 0.9, data["top_p"])
+	assert.Equal(t, 0, data["random_seed"])
+	assert.Equal(t, 100, data["max_tokens"])
+	assert.Equal(t, []string{"\n\n"}, data["stop"])
+	assert.Equal(t, 0.5, data["presence_penalty"])
+	assert.Equal(t, -0.5, data["frequency_penalty"])
+	assert.Equal(t, 2, data["n"])
+	assert.Equal(t, params.Prediction, data["prediction"])
+	assert.Equal(t, false, data["parallel_tool_calls"])
+	assert.Equal(t, PromptModeReasoning, data["prompt_mode"])
+	assert.Equal(t, true, data["stream"])
+}
@@ -2,12 +2,13 @@ package mistral
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -16,30 +17,24 @@ const (
 	CodestralEndpoint = "https://codestral.mistral.ai"
 	DefaultMaxRetries = 5
 	DefaultTimeout    = 120 * time.Second
+	DefaultUserAgent  = "mistral-go"
 )
 
-var retryStatusCodes = map[int]bool{
-	429: true,
-	500: true,
-	502: true,
-	503: true,
-	504: true,
-}
-
 type MistralClient struct {
-	apiKey     string
-	endpoint   string
-	maxRetries int
-	timeout    time.Duration
+	apiKey       string
+	endpoint     string
+	retryPolicy  RetryPolicy
+	timeout      time.Duration
+	httpClient   *http.Client
+	transport    http.RoundTripper
+	headers      http.Header
+	userAgent    string
+	organization string
+
+	validateToolResults bool
 }
 
 func NewMistralClient(apiKey string, endpoint string, maxRetries int, timeout time.Duration) *MistralClient {
-	if apiKey == "" {
-		apiKey = os.Getenv("MISTRAL_API_KEY")
-	}
-	if endpoint == "" {
-		endpoint = Endpoint
-	}
 	if maxRetries == 0 {
 		maxRetries = DefaultMaxRetries
 	}
@@ -47,12 +42,41 @@ func NewMistralClient(apiKey string, endpoint string, maxRetries int, timeout ti
 		timeout = DefaultTimeout
 	}
 
-	return &MistralClient{
-		apiKey:     apiKey,
-		endpoint:   endpoint,
-		maxRetries: maxRetries,
-		timeout:    timeout,
+	retryPolicy := DefaultRetryPolicy()
+	retryPolicy.MaxAttempts = maxRetries
+
+	return NewMistralClientWithOptions(apiKey, WithBaseURL(endpoint), WithRetryPolicy(retryPolicy), WithTimeout(timeout))
+}
+
+// NewMistralClientWithOptions creates a new Mistral API client configured by the given options. Defaults to using
+// MISTRAL_API_KEY from the environment when apiKey is empty, and to the public API endpoint when no base URL is set.
+func NewMistralClientWithOptions(apiKey string, opts ...ClientOption) *MistralClient {
+	if apiKey == "" {
+		apiKey = os.Getenv("MISTRAL_API_KEY")
+	}
+
+	c := &MistralClient{
+		apiKey:      apiKey,
+		endpoint:    Endpoint,
+		retryPolicy: DefaultRetryPolicy(),
+		timeout:     DefaultTimeout,
+		headers:     http.Header{},
+		userAgent:   DefaultUserAgent,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: c.timeout}
+	}
+	if c.transport != nil {
+		httpClient := *c.httpClient
+		httpClient.Transport = c.transport
+		c.httpClient = &httpClient
+	}
+
+	return c
 }
 
 // NewMistralClientDefault creates a new Mistral API client with the default endpoint and the given API key. Defaults to using MISTRAL_API_KEY from the environment.
@@ -73,44 +97,52 @@ func NewCodestralClientDefault(apiKey string) *MistralClient {
 	return NewMistralClient(apiKey, CodestralEndpoint, DefaultMaxRetries, DefaultTimeout)
 }
 
-func (c *MistralClient) request(method string, jsonData map[string]interface{}, path string, stream bool, params map[string]string) (interface{}, error) {
+func (c *MistralClient) request(ctx context.Context, method string, jsonData map[string]interface{}, path string, stream bool, params map[string]string) (interface{}, error) {
 	uri, err := url.Parse(c.endpoint)
 	if err != nil {
 		return nil, err
 	}
-	uri.Path = path
-	jsonValue, _ := json.Marshal(jsonData)
-	req, err := http.NewRequest(method, uri.String(), bytes.NewBuffer(jsonValue))
+	uri.Path = strings.TrimSuffix(uri.Path, "/") + "/" + path
+
+	var body io.Reader
+	if jsonData != nil {
+		jsonValue, err := json.Marshal(jsonData)
+		if err != nil {
+			return nil, err
+		}
+		// A bytes.Reader body lets http.NewRequest populate GetBody so the body can be replayed on retries.
+		body = bytes.NewReader(jsonValue)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, uri.String(), body)
 	if err != nil {
 		return nil, err
 	}
 
+	for key, values := range c.headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{
-		Timeout: c.timeout,
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	if c.organization != "" {
+		req.Header.Set(OrganizationHeader, c.organization)
 	}
 
-	var resp *http.Response
-	for i := 0; i < c.maxRetries; i++ {
-		resp, err = client.Do(req)
-		if err != nil {
-			if i == c.maxRetries-1 {
-				return nil, err
-			}
-			continue
-		}
-		if _, ok := retryStatusCodes[resp.StatusCode]; ok {
-			time.Sleep(time.Duration(i+1) * 500 * time.Millisecond)
-			continue
-		}
-		break
+	resp, attempts, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
 	}
 
 	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
 		responseBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("(HTTP Error %d) %s", resp.StatusCode, string(responseBytes))
+		apiErr := newAPIErrorFromResponse(resp, responseBytes)
+		apiErr.Attempts = attempts
+		return nil, apiErr
 	}
 
 	if stream {
@@ -118,16 +150,80 @@ func (c *MistralClient) request(method string, jsonData map[string]interface{},
 	}
 
 	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
 	}
 
 	var result map[string]interface{}
-	err = json.Unmarshal(body, &result)
+	err = json.Unmarshal(respBody, &result)
 	if err != nil {
 		return nil, err
 	}
 
 	return result, nil
 }
+
+// do sends the request, retrying according to the client's retry policy. Every attempt gets a fresh copy of the
+// request body. It returns the final response along with the number of attempts made. Transport failures are
+// returned as *MistralConnectionError.
+func (c *MistralClient) do(ctx context.Context, req *http.Request) (*http.Response, int, error) {
+	policy := c.retryPolicy
+	maxAttempts := policy.maxAttempts()
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 {
+			attemptReq = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, attempt - 1, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err := c.httpClient.Do(attemptReq)
+		if err != nil {
+			err = newConnectionError(err)
+		}
+		if attempt >= maxAttempts {
+			return resp, attempt, err
+		}
+		reason := policy.retryReason(ctx, resp, err)
+		if reason == "" {
+			return resp, attempt, err
+		}
+
+		event := RetryEvent{
+			Attempt: attempt,
+			Reason:  reason,
+			Err:     err,
+			Delay:   policy.delay(attempt, resp),
+		}
+		if resp != nil {
+			event.StatusCode = resp.StatusCode
+			drainAndClose(resp.Body)
+		}
+		if policy.OnRetry != nil {
+			policy.OnRetry(event)
+		}
+
+		if err := sleepContext(ctx, event.Delay); err != nil {
+			return nil, attempt, err
+		}
+	}
+}
+
+// sleepContext pauses for the given duration or until the context is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
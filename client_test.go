@@ -0,0 +1,127 @@
+package mistral
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// skipWithoutAPIKey skips a test against the live API when the environment variable holding its key is unset.
+// The mistraltest package covers the same endpoints offline.
+func skipWithoutAPIKey(t *testing.T, env string) {
+	t.Helper()
+	if os.Getenv(env) == "" {
+		t.Skipf("%s is not set", env)
+	}
+}
+
+func TestChatContextCancelDuringRetry(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	client := NewMistralClient("test", srv.URL, 5, time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	res, err := client.ChatContext(ctx, ModelMistralTiny, []ChatMessage{{Role: RoleUser, Content: "hi"}}, nil)
+	assert.Nil(t, res)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestChatStreamContextCancel(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for {
+			_, err := w.Write([]byte("data: {\"id\":\"1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"a\"}}]}\n\n"))
+			if err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	})
+
+	client := NewMistralClient("test", srv.URL, 1, time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	resChan, err := client.ChatStreamContext(ctx, ModelMistralTiny, []ChatMessage{{Role: RoleUser, Content: "hi"}}, nil)
+	assert.NoError(t, err)
+
+	res := <-resChan
+	assert.NoError(t, res.Error)
+	cancel()
+
+	// The goroutine must stop and close the channel once the context is cancelled.
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-resChan:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("stream channel was not closed after cancellation")
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestClientOptions(t *testing.T) {
+	var got *http.Request
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		got = req
+		rec := httptest.NewRecorder()
+		rec.WriteString(`{"object":"list","data":[{"id":"mistral-tiny"}]}`)
+		return rec.Result(), nil
+	})
+
+	client := NewMistralClientWithOptions(
+		"test",
+		WithTransport(transport),
+		WithBaseURL("https://proxy.example.com/mistral/"),
+		WithHeaders(http.Header{"X-Trace": []string{"abc"}}),
+		WithUserAgent("my-app/1.0"),
+		WithOrganization("org-1"),
+	)
+	res, err := client.ListModels()
+	assert.NoError(t, err)
+	assert.Equal(t, "mistral-tiny", res.Data[0].ID)
+
+	assert.Equal(t, "https://proxy.example.com/mistral/v1/models", got.URL.String())
+	assert.Equal(t, "Bearer test", got.Header.Get("Authorization"))
+	assert.Equal(t, "abc", got.Header.Get("X-Trace"))
+	assert.Equal(t, "my-app/1.0", got.Header.Get("User-Agent"))
+	assert.Equal(t, "org-1", got.Header.Get(OrganizationHeader))
+}
+
+func TestClientOptionsHTTPClient(t *testing.T) {
+	httpClient := &http.Client{Timeout: time.Second}
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("unused")
+	})
+
+	client := NewMistralClientWithOptions("test", WithHTTPClient(httpClient))
+	assert.Same(t, httpClient, client.httpClient)
+
+	client = NewMistralClientWithOptions("test", WithHTTPClient(httpClient), WithTransport(transport))
+	assert.NotSame(t, httpClient, client.httpClient)
+	assert.Nil(t, httpClient.Transport)
+	assert.Equal(t, time.Second, client.httpClient.Timeout)
+}
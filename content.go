@@ -0,0 +1,173 @@
+package mistral
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ContentType type of a part of a multimodal message
+type ContentType string
+
+const (
+	ContentTypeText        ContentType = "text"
+	ContentTypeImageURL    ContentType = "image_url"
+	ContentTypeDocumentURL ContentType = "document_url"
+)
+
+// ContentPart represents a typed part of a multimodal message content, such as text or an image.
+type ContentPart struct {
+	Type         ContentType `json:"type"`
+	Text         string      `json:"text,omitempty"`
+	ImageURL     *ImageURL   `json:"image_url,omitempty"`
+	DocumentURL  string      `json:"document_url,omitempty"`
+	DocumentName string      `json:"document_name,omitempty"`
+}
+
+// ImageURL is the location of an image, either a http(s) URL or a base64 data URI.
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// MarshalJSON encodes the image as a plain URL string unless a detail level is set.
+func (i ImageURL) MarshalJSON() ([]byte, error) {
+	if i.Detail == "" {
+		return json.Marshal(i.URL)
+	}
+	type imageURL ImageURL
+	return json.Marshal(imageURL(i))
+}
+
+// UnmarshalJSON accepts both the plain string and the object form of an image URL.
+func (i *ImageURL) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		*i = ImageURL{}
+		return json.Unmarshal(data, &i.URL)
+	}
+	type imageURL ImageURL
+	return json.Unmarshal(data, (*imageURL)(i))
+}
+
+// TextPart returns a text content part.
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: ContentTypeText, Text: text}
+}
+
+// ImageURLPart returns an image content part referencing an http(s) URL or a data URI.
+func ImageURLPart(url string) ContentPart {
+	return ContentPart{Type: ContentTypeImageURL, ImageURL: &ImageURL{URL: url}}
+}
+
+// DocumentURLPart returns a document content part referencing the URL of a document such as a PDF.
+func DocumentURLPart(url string, name string) ContentPart {
+	return ContentPart{Type: ContentTypeDocumentURL, DocumentURL: url, DocumentName: name}
+}
+
+// ImagePartFromBytes returns an image content part embedding the image as a base64 data URI. The MIME type is
+// detected from the image data.
+func ImagePartFromBytes(data []byte) (ContentPart, error) {
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return ContentPart{}, fmt.Errorf("unsupported image type %q", mimeType)
+	}
+	return ImageURLPart(dataURI(mimeType, data)), nil
+}
+
+// ImagePartFromFile returns an image content part embedding the image file at path as a base64 data URI.
+// The MIME type is detected from the file contents, falling back to the file extension.
+func ImagePartFromFile(path string) (ContentPart, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ContentPart{}, err
+	}
+
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType, _, _ = mime.ParseMediaType(mime.TypeByExtension(filepath.Ext(path)))
+	}
+	if !strings.HasPrefix(mimeType, "image/") {
+		return ContentPart{}, fmt.Errorf("unsupported image type for %s", path)
+	}
+	return ImageURLPart(dataURI(mimeType, data)), nil
+}
+
+func dataURI(mimeType string, data []byte) string {
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// MarshalJSON encodes the content as an array of parts when ContentParts is set and as a string otherwise.
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	type chatMessage ChatMessage
+	if len(m.ContentParts) == 0 {
+		return json.Marshal(chatMessage(m))
+	}
+	return json.Marshal(struct {
+		chatMessage
+		Content []ContentPart `json:"content"`
+	}{chatMessage(m), m.ContentParts})
+}
+
+// UnmarshalJSON accepts content as a string or an array of parts. Array content is kept in ContentParts and its
+// text parts are joined into Content.
+func (m *ChatMessage) UnmarshalJSON(data []byte) error {
+	type chatMessage ChatMessage
+	aux := struct {
+		*chatMessage
+		Content json.RawMessage `json:"content"`
+	}{chatMessage: (*chatMessage)(m)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	m.Content, m.ContentParts, err = decodeContent(aux.Content)
+	return err
+}
+
+// UnmarshalJSON accepts content as a string or an array of parts, joining the text parts into Content.
+func (d *DeltaMessage) UnmarshalJSON(data []byte) error {
+	type deltaMessage DeltaMessage
+	aux := struct {
+		*deltaMessage
+		Content json.RawMessage `json:"content"`
+	}{deltaMessage: (*deltaMessage)(d)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	d.Content, _, err = decodeContent(aux.Content)
+	return err
+}
+
+// decodeContent decodes message content that is either null, a string or an array of parts.
+func decodeContent(raw json.RawMessage) (string, []ContentPart, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return "", nil, nil
+	}
+	if raw[0] == '"' {
+		var content string
+		err := json.Unmarshal(raw, &content)
+		return content, nil, err
+	}
+
+	var parts []ContentPart
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", nil, fmt.Errorf("invalid message content: %w", err)
+	}
+	var text strings.Builder
+	for _, part := range parts {
+		if part.Type == ContentTypeText {
+			text.WriteString(part.Text)
+		}
+	}
+	return text.String(), parts, nil
+}
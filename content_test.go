@@ -0,0 +1,82 @@
+package mistral
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChatMessageContentPartsJSON(t *testing.T) {
+	msg := ChatMessage{
+		Role: RoleUser,
+		ContentParts: []ContentPart{
+			TextPart("What's in this image?"),
+			ImageURLPart("https://example.com/cat.png"),
+			DocumentURLPart("https://example.com/paper.pdf", "paper.pdf"),
+		},
+	}
+	data, err := json.Marshal(msg)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"role":"user","content":[
+		{"type":"text","text":"What's in this image?"},
+		{"type":"image_url","image_url":"https://example.com/cat.png"},
+		{"type":"document_url","document_url":"https://example.com/paper.pdf","document_name":"paper.pdf"}
+	]}`, string(data))
+
+	var decoded ChatMessage
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "What's in this image?", decoded.Content)
+	assert.Equal(t, msg.ContentParts, decoded.ContentParts)
+
+	data, err = json.Marshal(ChatMessage{Role: RoleUser, Content: "hi"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"role":"user","content":"hi"}`, string(data))
+}
+
+func TestChatMessageContentDecoding(t *testing.T) {
+	var res ChatCompletionResponse
+	err := json.Unmarshal([]byte(`{"choices":[
+		{"index":0,"message":{"role":"assistant","content":[{"type":"text","text":"A "},{"type":"thinking","thinking":[{"type":"text","text":"hmm"}]},{"type":"text","text":"cat."}]}},
+		{"index":1,"message":{"role":"assistant","content":null,"tool_calls":[{"id":"1","type":"function","function":{"name":"f","arguments":"{}"}}]}}
+	]}`), &res)
+	assert.NoError(t, err)
+	assert.Equal(t, "A cat.", res.Choices[0].Message.Content)
+	assert.Len(t, res.Choices[0].Message.ContentParts, 3)
+	assert.Equal(t, "", res.Choices[1].Message.Content)
+	assert.Len(t, res.Choices[1].Message.ToolCalls, 1)
+
+	var chunk ChatCompletionStreamResponse
+	err = json.Unmarshal([]byte(`{"choices":[{"index":0,"delta":{"content":[{"type":"text","text":"streamed"}]}}]}`), &chunk)
+	assert.NoError(t, err)
+	assert.Equal(t, "streamed", chunk.Choices[0].Delta.Content)
+}
+
+func TestImageURLJSON(t *testing.T) {
+	var image ImageURL
+	assert.NoError(t, json.Unmarshal([]byte(`{"url":"https://example.com/a.png","detail":"high"}`), &image))
+	assert.Equal(t, ImageURL{URL: "https://example.com/a.png", Detail: "high"}, image)
+
+	data, err := json.Marshal(image)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"url":"https://example.com/a.png","detail":"high"}`, string(data))
+}
+
+func TestImagePartFromFile(t *testing.T) {
+	part, err := ImagePartFromFile(filepath.Join("testdata", "pixel.png"))
+	assert.NoError(t, err)
+	assert.Equal(t, ContentTypeImageURL, part.Type)
+	assert.True(t, strings.HasPrefix(part.ImageURL.URL, "data:image/png;base64,iVBORw0KGgo"))
+
+	data, err := os.ReadFile(filepath.Join("testdata", "pixel.png"))
+	assert.NoError(t, err)
+	fromBytes, err := ImagePartFromBytes(data)
+	assert.NoError(t, err)
+	assert.Equal(t, part, fromBytes)
+
+	_, err = ImagePartFromBytes([]byte("not an image"))
+	assert.Error(t, err)
+}
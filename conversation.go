@@ -0,0 +1,437 @@
+package mistral
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultConversationReserveTokens is the part of the context window a Conversation keeps free for the
+	// completion when the request does not set MaxTokens.
+	DefaultConversationReserveTokens = 1024
+
+	// DefaultSummaryPrompt instructs the model used by ClientSummarizer.
+	DefaultSummaryPrompt = "You maintain a running summary of a conversation between a user and an assistant. " +
+		"Update the summary with the new messages. Keep every fact, decision, name and open question that later turns " +
+		"may need, drop pleasantries, and answer with the updated summary only."
+)
+
+// ErrConversationTooLong is returned when the latest turn of a conversation does not fit in its token budget on its
+// own.
+var ErrConversationTooLong = errors.New("conversation does not fit in the token budget")
+
+// TokenCounter counts the prompt tokens of a list of messages. *tokenizer.ChatTemplate implements it with exact
+// counts; EstimateTokens is a dependency-free approximation.
+type TokenCounter interface {
+	CountTokens(messages []ChatMessage) (int, error)
+}
+
+// TokenCounterFunc adapts a function to the TokenCounter interface.
+type TokenCounterFunc func(messages []ChatMessage) (int, error)
+
+// CountTokens calls f(messages).
+func (f TokenCounterFunc) CountTokens(messages []ChatMessage) (int, error) {
+	return f(messages)
+}
+
+// EstimateTokens approximates the prompt tokens of messages at one token per four bytes of text plus a few tokens
+// of formatting per message. It tends to overestimate for English text; use the tokenizer package for exact counts.
+func EstimateTokens(messages []ChatMessage) (int, error) {
+	tokens := 1
+	for _, msg := range messages {
+		size := len(msg.Content) + len(msg.ToolCallId) + len(msg.Name)
+		for _, part := range msg.ContentParts {
+			size += len(part.Text)
+		}
+		for _, call := range msg.ToolCalls {
+			size += len(call.Id) + len(call.Function.Name) + len(call.Function.Arguments) + 16
+		}
+		tokens += 4 + (size+3)/4
+	}
+	return tokens, nil
+}
+
+// Summarizer condenses messages evicted from a Conversation into its rolling summary.
+type Summarizer interface {
+	// Summarize returns the summary updated with the evicted messages. summary is empty the first time.
+	Summarize(ctx context.Context, summary string, messages []ChatMessage) (string, error)
+}
+
+// SummarizerFunc adapts a function to the Summarizer interface.
+type SummarizerFunc func(ctx context.Context, summary string, messages []ChatMessage) (string, error)
+
+// Summarize calls f(ctx, summary, messages).
+func (f SummarizerFunc) Summarize(ctx context.Context, summary string, messages []ChatMessage) (string, error) {
+	return f(ctx, summary, messages)
+}
+
+// ClientSummarizer summarizes evicted messages with a chat completion.
+type ClientSummarizer struct {
+	Client *MistralClient
+	Model  string
+	Prompt string             // The system prompt of the summary request. Defaults to DefaultSummaryPrompt.
+	Params *ChatRequestParams // Parameters of the summary request; nil uses the defaults.
+}
+
+// Summarize asks the model to fold the messages into the summary.
+func (s *ClientSummarizer) Summarize(ctx context.Context, summary string, messages []ChatMessage) (string, error) {
+	prompt := s.Prompt
+	if prompt == "" {
+		prompt = DefaultSummaryPrompt
+	}
+
+	var b strings.Builder
+	if summary != "" {
+		b.WriteString("Current summary:\n" + summary + "\n\n")
+	}
+	b.WriteString("New messages:\n")
+	for _, msg := range messages {
+		b.WriteString(transcriptLine(msg) + "\n")
+	}
+
+	res, err := s.Client.ChatContext(ctx, s.Model, []ChatMessage{SystemMessage(prompt), UserMessage(b.String())}, s.Params)
+	if err != nil {
+		return "", fmt.Errorf("error summarizing conversation: %w", err)
+	}
+	if len(res.Choices) == 0 {
+		return "", errors.New("error summarizing conversation: response has no choices")
+	}
+	return strings.TrimSpace(res.Choices[0].Message.Content), nil
+}
+
+// transcriptLine renders a message as one line of a plain text transcript.
+func transcriptLine(msg ChatMessage) string {
+	content := msg.Content
+	for _, part := range msg.ContentParts {
+		if part.Type == ContentTypeText {
+			content += part.Text
+		} else {
+			content += "[" + string(part.Type) + "]"
+		}
+	}
+	for _, call := range msg.ToolCalls {
+		content += fmt.Sprintf(" [called %s(%s)]", call.Function.Name, call.Function.Arguments)
+	}
+	if msg.Role == RoleTool && msg.Name != "" {
+		return fmt.Sprintf("%s (%s): %s", msg.Role, msg.Name, strings.TrimSpace(content))
+	}
+	return fmt.Sprintf("%s: %s", msg.Role, strings.TrimSpace(content))
+}
+
+// ConversationMessage is a message of a Conversation with the time it was added.
+type ConversationMessage struct {
+	Message   ChatMessage `json:"message"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// ConversationOptions configures a Conversation.
+type ConversationOptions struct {
+	ID     string             // Identifies the conversation in a ConversationStore. A random id is generated when empty.
+	Model  string             // The model the conversation is sent to. Required by Conversation.Chat.
+	Params *ChatRequestParams // The parameters Conversation.Chat uses when called without any.
+
+	// MaxPromptTokens is the token budget of the prompt. Defaults to the context window of Model minus the tokens
+	// reserved for the completion; when neither is known the conversation is never truncated.
+	MaxPromptTokens int
+	ReserveTokens   int          // Tokens kept free for the completion. Defaults to DefaultConversationReserveTokens.
+	TokenCounter    TokenCounter // Counts the prompt tokens. Defaults to EstimateTokens.
+	Summarizer      Summarizer   // Folds evicted turns into a summary. Evicted turns are dropped when nil.
+}
+
+// Conversation keeps the history of a chat and keeps it within the model's context window.
+//
+// When the prompt exceeds its token budget the oldest turns are evicted. A turn is a user message together with the
+// assistant messages, tool calls and tool results that follow it, so tool results are never separated from their
+// tool calls. System messages are never evicted. With a Summarizer, evicted turns are folded into a rolling summary
+// that is sent as a system message after the leading system messages.
+//
+// A Conversation is safe for concurrent use.
+type Conversation struct {
+	mu         sync.Mutex
+	opts       ConversationOptions
+	messages   []ConversationMessage
+	summary    string
+	generation int // Incremented whenever the messages are replaced rather than appended to.
+	usage      UsageInfo
+	createdAt  time.Time
+	updatedAt  time.Time
+}
+
+// NewConversation creates an empty conversation. A nil opts uses the defaults and never truncates.
+func NewConversation(opts *ConversationOptions) *Conversation {
+	c := &Conversation{createdAt: time.Now()}
+	c.updatedAt = c.createdAt
+	if opts != nil {
+		c.opts = *opts
+	}
+	if c.opts.ID == "" {
+		c.opts.ID = newConversationID()
+	}
+	return c
+}
+
+func newConversationID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("conv-%d", time.Now().UnixNano())
+	}
+	return "conv-" + hex.EncodeToString(b)
+}
+
+// ID returns the id of the conversation.
+func (c *Conversation) ID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.opts.ID
+}
+
+// Model returns the model the conversation is sent to.
+func (c *Conversation) Model() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.opts.Model
+}
+
+// Usage returns the usage summed over every request made by Chat.
+func (c *Conversation) Usage() UsageInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.usage
+}
+
+// Append adds messages to the end of the conversation.
+func (c *Conversation) Append(messages ...ChatMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for _, msg := range messages {
+		c.messages = append(c.messages, ConversationMessage{Message: msg, CreatedAt: now})
+	}
+	c.updatedAt = now
+}
+
+// Messages returns the prompt to send: the retained messages with the summary of evicted turns, if any.
+func (c *Conversation) Messages() []ChatMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return buildConversationPrompt(c.messages, c.summary)
+}
+
+// History returns the retained messages, without the summary.
+func (c *Conversation) History() []ConversationMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ConversationMessage(nil), c.messages...)
+}
+
+// Summary returns the rolling summary of the evicted turns.
+func (c *Conversation) Summary() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.summary
+}
+
+// Fit evicts the oldest turns until the prompt fits in the token budget, summarizing them if the conversation has
+// a Summarizer. It returns an error wrapping ErrConversationTooLong if the latest turn does not fit on its own. Turns
+// are only evicted once they are summarized, so on an error the turns not summarized yet are kept.
+//
+// The conversation is not locked while the Summarizer runs: messages appended meanwhile are kept and counted, and
+// the summary is discarded and the eviction started over if the history is replaced meanwhile.
+func (c *Conversation) Fit(ctx context.Context) error {
+	_, err := c.fit(ctx, nil)
+	return err
+}
+
+// Chat fits the conversation into the budget left by params.MaxTokens, sends it to the model and appends the answer.
+// A nil params uses the conversation's Params.
+func (c *Conversation) Chat(ctx context.Context, client *MistralClient, params *ChatRequestParams) (*ChatCompletionResponse, error) {
+	c.mu.Lock()
+	model := c.opts.Model
+	if params == nil {
+		params = c.opts.Params
+	}
+	c.mu.Unlock()
+	if model == "" {
+		return nil, errors.New("conversation has no model")
+	}
+
+	prompt, err := c.fit(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.ChatContext(ctx, model, prompt, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Choices) == 0 {
+		return res, errors.New("response has no choices")
+	}
+
+	c.mu.Lock()
+	c.usage.PromptTokens += res.Usage.PromptTokens
+	c.usage.CompletionTokens += res.Usage.CompletionTokens
+	c.usage.TotalTokens += res.Usage.TotalTokens
+	c.mu.Unlock()
+	c.Append(res.Choices[0].Message)
+	return res, nil
+}
+
+func (c *Conversation) reserveTokens(params *ChatRequestParams) int {
+	if params != nil && params.MaxTokens != nil {
+		return *params.MaxTokens
+	}
+	if c.opts.ReserveTokens > 0 {
+		return c.opts.ReserveTokens
+	}
+	return DefaultConversationReserveTokens
+}
+
+// budget returns the prompt token budget, or 0 if it is unlimited.
+func (c *Conversation) budget(reserve int) int {
+	if c.opts.MaxPromptTokens > 0 {
+		return c.opts.MaxPromptTokens
+	}
+	window, ok := ContextWindow(c.opts.Model)
+	if !ok {
+		return 0
+	}
+	if window-reserve < 1 {
+		return 1
+	}
+	return window - reserve
+}
+
+// fit evicts the oldest turns until the prompt fits in the budget left by params and returns the prompt. The lock is
+// released while the Summarizer runs, and its summary is applied only if the messages were not replaced meanwhile.
+func (c *Conversation) fit(ctx context.Context, params *ChatRequestParams) ([]ChatMessage, error) {
+	for {
+		c.mu.Lock()
+		retained, evicted, err := c.evict(c.reserveTokens(params))
+		if err != nil {
+			c.mu.Unlock()
+			return nil, err
+		}
+		if len(evicted) == 0 || c.opts.Summarizer == nil {
+			if len(evicted) > 0 {
+				c.replaceMessages(retained, c.summary)
+			}
+			prompt := buildConversationPrompt(c.messages, c.summary)
+			c.mu.Unlock()
+			return prompt, nil
+		}
+		summarizer, summary, generation, size := c.opts.Summarizer, c.summary, c.generation, len(c.messages)
+		c.mu.Unlock()
+
+		summary, err = summarizer.Summarize(ctx, summary, evicted)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		if c.generation == generation {
+			// Only Append can have run meanwhile, so the messages it added follow the snapshot.
+			c.replaceMessages(append(retained, c.messages[size:]...), summary)
+		}
+		c.mu.Unlock()
+		// The summary grows with the evicted turns, so the prompt is counted again.
+	}
+}
+
+// evict returns the messages retained and the messages of the oldest turns evicted for the prompt to fit in the
+// budget left by reserve with the current summary.
+func (c *Conversation) evict(reserve int) ([]ConversationMessage, []ChatMessage, error) {
+	messages := c.messages
+	budget := c.budget(reserve)
+	if budget == 0 {
+		return messages, nil, nil
+	}
+
+	var evicted []ChatMessage
+	for {
+		tokens, err := c.tokenCounter().CountTokens(buildConversationPrompt(messages, c.summary))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error counting tokens: %w", err)
+		}
+		if tokens <= budget {
+			return messages, evicted, nil
+		}
+
+		turns := conversationTurns(messages)
+		if len(turns) <= 1 {
+			return nil, nil, fmt.Errorf("%w: %d prompt tokens with a budget of %d", ErrConversationTooLong, tokens, budget)
+		}
+
+		oldest := map[int]bool{}
+		for _, i := range turns[0] {
+			oldest[i] = true
+			evicted = append(evicted, messages[i].Message)
+		}
+		retained := make([]ConversationMessage, 0, len(messages)-len(turns[0]))
+		for i, msg := range messages {
+			if !oldest[i] {
+				retained = append(retained, msg)
+			}
+		}
+		messages = retained
+	}
+}
+
+// replaceMessages sets the messages and the summary after an eviction.
+func (c *Conversation) replaceMessages(messages []ConversationMessage, summary string) {
+	c.messages, c.summary = messages, summary
+	c.generation++
+	c.updatedAt = time.Now()
+}
+
+func (c *Conversation) tokenCounter() TokenCounter {
+	if c.opts.TokenCounter == nil {
+		return TokenCounterFunc(EstimateTokens)
+	}
+	return c.opts.TokenCounter
+}
+
+// conversationTurns groups the indices of the messages other than system messages into turns, each starting at a
+// user message. Messages before the first user message form a turn of their own.
+func conversationTurns(messages []ConversationMessage) [][]int {
+	var turns [][]int
+	for i, msg := range messages {
+		switch {
+		case msg.Message.Role == RoleSystem:
+			continue
+		case msg.Message.Role == RoleUser || len(turns) == 0:
+			turns = append(turns, []int{i})
+		default:
+			turns[len(turns)-1] = append(turns[len(turns)-1], i)
+		}
+	}
+	return turns
+}
+
+// buildConversationPrompt returns the messages to send, with the summary inserted after the leading system messages.
+func buildConversationPrompt(messages []ConversationMessage, summary string) []ChatMessage {
+	prompt := make([]ChatMessage, 0, len(messages)+1)
+	inserted := summary == ""
+	for _, msg := range messages {
+		if !inserted && msg.Message.Role != RoleSystem {
+			prompt = append(prompt, summaryMessage(summary))
+			inserted = true
+		}
+		prompt = append(prompt, msg.Message)
+	}
+	if !inserted {
+		prompt = append(prompt, summaryMessage(summary))
+	}
+	return prompt
+}
+
+func summaryMessage(summary string) ChatMessage {
+	return SystemMessage("Summary of the earlier conversation:\n" + summary)
+}
@@ -0,0 +1,277 @@
+package mistral
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ConversationRecord is the serializable state of a Conversation.
+type ConversationRecord struct {
+	ID        string                `json:"id"`
+	Model     string                `json:"model,omitempty"`
+	Params    *ChatRequestParams    `json:"params,omitempty"`
+	Summary   string                `json:"summary,omitempty"` // The rolling summary of evicted turns.
+	Messages  []ConversationMessage `json:"messages,omitempty"`
+	Usage     UsageInfo             `json:"usage"`
+	CreatedAt time.Time             `json:"created_at"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+// Record returns a snapshot of the conversation that can be serialized and restored with RestoreConversation.
+func (c *Conversation) Record() ConversationRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The params are copied so that changing the record does not change the conversation.
+	var params *ChatRequestParams
+	if c.opts.Params != nil {
+		p := *c.opts.Params
+		params = &p
+	}
+	return ConversationRecord{
+		ID:        c.opts.ID,
+		Model:     c.opts.Model,
+		Params:    params,
+		Summary:   c.summary,
+		Messages:  append([]ConversationMessage(nil), c.messages...),
+		Usage:     c.usage,
+		CreatedAt: c.createdAt,
+		UpdatedAt: c.updatedAt,
+	}
+}
+
+// RestoreConversation recreates a conversation from a record. opts supplies the settings that are not serialized,
+// such as the TokenCounter and Summarizer; the id, model and params of the record take precedence over those of opts
+// unless the record leaves them empty.
+func RestoreConversation(record ConversationRecord, opts *ConversationOptions) *Conversation {
+	var o ConversationOptions
+	if opts != nil {
+		o = *opts
+	}
+	if record.ID != "" {
+		o.ID = record.ID
+	}
+	if record.Model != "" {
+		o.Model = record.Model
+	}
+	if record.Params != nil {
+		o.Params = record.Params
+	}
+
+	c := NewConversation(&o)
+	c.restore(record)
+	return c
+}
+
+func (c *Conversation) restore(record ConversationRecord) {
+	c.messages = append([]ConversationMessage(nil), record.Messages...)
+	c.summary = record.Summary
+	c.generation++
+	c.usage = record.Usage
+	if !record.CreatedAt.IsZero() {
+		c.createdAt = record.CreatedAt
+	}
+	if !record.UpdatedAt.IsZero() {
+		c.updatedAt = record.UpdatedAt
+	}
+}
+
+// MarshalJSON encodes the conversation as its ConversationRecord.
+func (c *Conversation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.Record())
+}
+
+// UnmarshalJSON restores the conversation from an encoded ConversationRecord, keeping the options that are not
+// serialized.
+func (c *Conversation) UnmarshalJSON(data []byte) error {
+	var record ConversationRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.opts.ID, c.opts.Model, c.opts.Params = record.ID, record.Model, record.Params
+	c.restore(record)
+	return nil
+}
+
+const (
+	jsonlConversationType = "conversation"
+	jsonlMessageType      = "message"
+)
+
+type jsonlConversationHeader struct {
+	Type string `json:"type"`
+	ConversationRecord
+}
+
+type jsonlConversationMessage struct {
+	Type string `json:"type"`
+	ConversationMessage
+}
+
+// WriteJSONL writes the conversation as JSON Lines: a header line with the conversation's metadata followed by one
+// line per message, so transcripts can be appended to and processed line by line.
+func (c *Conversation) WriteJSONL(w io.Writer) error {
+	record := c.Record()
+	messages := record.Messages
+	record.Messages = nil
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(jsonlConversationHeader{Type: jsonlConversationType, ConversationRecord: record}); err != nil {
+		return err
+	}
+	for _, msg := range messages {
+		if err := enc.Encode(jsonlConversationMessage{Type: jsonlMessageType, ConversationMessage: msg}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadConversationJSONL reads a conversation written by WriteJSONL. opts is used as in RestoreConversation.
+func ReadConversationJSONL(r io.Reader, opts *ConversationOptions) (*Conversation, error) {
+	dec := json.NewDecoder(r)
+
+	var header jsonlConversationHeader
+	if err := dec.Decode(&header); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("error reading conversation: empty input")
+		}
+		return nil, fmt.Errorf("error reading conversation: %w", err)
+	}
+	if header.Type != jsonlConversationType {
+		return nil, fmt.Errorf("error reading conversation: expected a %q line but got %q", jsonlConversationType, header.Type)
+	}
+
+	record := header.ConversationRecord
+	for line := 2; ; line++ {
+		var msg jsonlConversationMessage
+		err := dec.Decode(&msg)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading conversation line %d: %w", line, err)
+		}
+		if msg.Type != jsonlMessageType {
+			return nil, fmt.Errorf("error reading conversation line %d: unexpected type %q", line, msg.Type)
+		}
+		record.Messages = append(record.Messages, msg.ConversationMessage)
+	}
+
+	return RestoreConversation(record, opts), nil
+}
+
+// WriteMarkdown writes a human readable transcript of the conversation: its metadata, the summary of evicted turns
+// and every message with its timestamp, tool calls and tool results.
+func (c *Conversation) WriteMarkdown(w io.Writer) error {
+	record := c.Record()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Conversation %s\n\n", record.ID)
+	if record.Model != "" {
+		fmt.Fprintf(&b, "- **Model:** %s\n", record.Model)
+	}
+	fmt.Fprintf(&b, "- **Created:** %s\n", formatTranscriptTime(record.CreatedAt))
+	fmt.Fprintf(&b, "- **Updated:** %s\n", formatTranscriptTime(record.UpdatedAt))
+	fmt.Fprintf(&b, "- **Usage:** %d prompt tokens, %d completion tokens, %d total tokens\n",
+		record.Usage.PromptTokens, record.Usage.CompletionTokens, record.Usage.TotalTokens)
+	if record.Params != nil {
+		params, err := json.MarshalIndent(record.Params, "", "  ")
+		if err != nil {
+			return err
+		}
+		b.WriteString("\n**Parameters:**\n\n" + markdownCodeBlock(string(params), "json"))
+	}
+
+	if record.Summary != "" {
+		b.WriteString("\n## Summary of earlier turns\n\n" + record.Summary + "\n")
+	}
+
+	b.WriteString("\n## Transcript\n")
+	for _, msg := range record.Messages {
+		writeMarkdownMessage(&b, msg)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownMessage(b *strings.Builder, msg ConversationMessage) {
+	m := msg.Message
+	title := "Message"
+	if m.Role != "" {
+		title = strings.ToUpper(m.Role[:1]) + m.Role[1:]
+	}
+	if m.Role == RoleTool {
+		title = "Tool result"
+		if m.Name != "" {
+			title += " `" + m.Name + "`"
+		}
+		if m.ToolCallId != "" {
+			title += " (call `" + m.ToolCallId + "`)"
+		}
+	}
+	fmt.Fprintf(b, "\n### %s · %s\n\n", title, formatTranscriptTime(msg.CreatedAt))
+
+	if m.Role == RoleTool {
+		b.WriteString(markdownCodeBlock(m.Content, ""))
+	} else if m.Content != "" {
+		b.WriteString(m.Content + "\n")
+	}
+	for _, part := range m.ContentParts {
+		switch part.Type {
+		case ContentTypeText:
+			b.WriteString(part.Text + "\n")
+		case ContentTypeImageURL:
+			if part.ImageURL != nil && !strings.HasPrefix(part.ImageURL.URL, "data:") {
+				fmt.Fprintf(b, "![image](%s)\n", part.ImageURL.URL)
+			} else {
+				b.WriteString("_[inline image]_\n")
+			}
+		case ContentTypeDocumentURL:
+			name := part.DocumentName
+			if name == "" {
+				name = part.DocumentURL
+			}
+			fmt.Fprintf(b, "[%s](%s)\n", name, part.DocumentURL)
+		}
+	}
+
+	for _, call := range m.ToolCalls {
+		fmt.Fprintf(b, "\n**Tool call** `%s` (id `%s`):\n\n", call.Function.Name, call.Id)
+		b.WriteString(markdownCodeBlock(call.Function.Arguments, "json"))
+	}
+}
+
+func formatTranscriptTime(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// markdownCodeBlock fences content with enough backticks that fences inside the content do not end the block.
+func markdownCodeBlock(content string, lang string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	fence := "```"
+	if longest >= 3 {
+		fence = strings.Repeat("`", longest+1)
+	}
+	return fence + lang + "\n" + strings.TrimRight(content, "\n") + "\n" + fence + "\n"
+}
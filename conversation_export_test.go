@@ -0,0 +1,147 @@
+package mistral
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newExportConversation() *Conversation {
+	call := ToolCall{Id: "call1", Type: ToolTypeFunction, Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}}
+	c := NewConversation(&ConversationOptions{
+		ID:     "conv-test",
+		Model:  ModelMistralSmallLatest,
+		Params: &ChatRequestParams{Temperature: Ptr(0.2)},
+	})
+	c.Append(
+		SystemMessage("You are helpful."),
+		UserMessage("What is the weather in Paris?"),
+		ChatMessage{Role: RoleAssistant, ToolCalls: []ToolCall{call}},
+		ChatMessage{Role: RoleTool, Name: "get_weather", Content: "sunny", ToolCallId: call.Id},
+		AssistantMessage("It is sunny."),
+	)
+	c.usage = UsageInfo{PromptTokens: 30, CompletionTokens: 10, TotalTokens: 40}
+	c.summary = "The user said hello."
+	return c
+}
+
+func TestConversationJSONRoundTrip(t *testing.T) {
+	c := newExportConversation()
+
+	data, err := json.Marshal(c)
+	assert.NoError(t, err)
+
+	restored := NewConversation(&ConversationOptions{TokenCounter: countMessages})
+	assert.NoError(t, json.Unmarshal(data, restored))
+
+	assert.Equal(t, "conv-test", restored.ID())
+	assert.Equal(t, ModelMistralSmallLatest, restored.Model())
+	assert.Equal(t, c.Usage(), restored.Usage())
+	assert.Equal(t, c.Summary(), restored.Summary())
+	assert.Equal(t, 0.2, *restored.opts.Params.Temperature)
+	assert.Equal(t, historyContents(c.History()), historyContents(restored.History()))
+	assert.Equal(t, c.History()[2].Message.ToolCalls, restored.History()[2].Message.ToolCalls)
+	assert.True(t, c.History()[0].CreatedAt.Equal(restored.History()[0].CreatedAt))
+	assert.True(t, c.Record().UpdatedAt.Equal(restored.Record().UpdatedAt))
+	// The options that are not serialized are kept.
+	assert.NotNil(t, restored.opts.TokenCounter)
+}
+
+func TestConversationRecordCopiesParams(t *testing.T) {
+	c := newExportConversation()
+
+	record := c.Record()
+	record.Params.SafePrompt = true
+	assert.False(t, c.Record().Params.SafePrompt)
+	assert.NotSame(t, c.Record().Params, c.Record().Params)
+}
+
+func TestConversationUnmarshalJSONConcurrentReads(t *testing.T) {
+	data, err := json.Marshal(newExportConversation())
+	assert.NoError(t, err)
+	restored := NewConversation(nil)
+
+	// Run with -race: the accessors must not read the options while they are being restored.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			restored.ID()
+			restored.Model()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, json.Unmarshal(data, restored))
+	}
+	<-done
+	assert.Equal(t, "conv-test", restored.ID())
+}
+
+func TestConversationJSONLRoundTrip(t *testing.T) {
+	c := newExportConversation()
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.WriteJSONL(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 6) {
+		assert.Contains(t, lines[0], `"type":"conversation"`)
+		assert.NotContains(t, lines[0], `"messages"`)
+		assert.Contains(t, lines[3], `"type":"message"`)
+		assert.Contains(t, lines[3], `"tool_calls"`)
+		assert.Contains(t, lines[4], `"tool_call_id":"call1"`)
+	}
+
+	restored, err := ReadConversationJSONL(&buf, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, c.ID(), restored.ID())
+	assert.Equal(t, c.Usage(), restored.Usage())
+	assert.Equal(t, historyContents(c.History()), historyContents(restored.History()))
+}
+
+func TestReadConversationJSONLErrors(t *testing.T) {
+	_, err := ReadConversationJSONL(strings.NewReader(""), nil)
+	assert.ErrorContains(t, err, "empty input")
+
+	_, err = ReadConversationJSONL(strings.NewReader(`{"type":"message"}`), nil)
+	assert.ErrorContains(t, err, `expected a "conversation" line`)
+
+	_, err = ReadConversationJSONL(strings.NewReader("{\"type\":\"conversation\",\"id\":\"c\"}\n{\"type\":\"message\"\n"), nil)
+	assert.ErrorContains(t, err, "line 2")
+
+	_, err = ReadConversationJSONL(strings.NewReader("{\"type\":\"conversation\",\"id\":\"c\"}\n{\"type\":\"other\"}\n"), nil)
+	assert.ErrorContains(t, err, `unexpected type "other"`)
+}
+
+func TestConversationWriteMarkdown(t *testing.T) {
+	c := newExportConversation()
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	c.createdAt = created
+	for i := range c.messages {
+		c.messages[i].CreatedAt = created
+	}
+	c.messages[1].Message.Content = "Show me ```code```"
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.WriteMarkdown(&buf))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "# Conversation conv-test\n"))
+	assert.Contains(t, out, "- **Model:** "+ModelMistralSmallLatest+"\n")
+	assert.Contains(t, out, "- **Created:** 2024-05-01T12:00:00Z\n")
+	assert.Contains(t, out, "- **Usage:** 30 prompt tokens, 10 completion tokens, 40 total tokens\n")
+	assert.Contains(t, out, "\"temperature\": 0.2")
+	assert.Contains(t, out, "## Summary of earlier turns\n\nThe user said hello.\n")
+	assert.Contains(t, out, "### User · 2024-05-01T12:00:00Z\n\nShow me ```code```\n")
+	assert.Contains(t, out, "**Tool call** `get_weather` (id `call1`):\n\n```json\n{\"city\":\"Paris\"}\n```\n")
+	assert.Contains(t, out, "### Tool result `get_weather` (call `call1`) · 2024-05-01T12:00:00Z\n\n```\nsunny\n```\n")
+	assert.Contains(t, out, "### Assistant · 2024-05-01T12:00:00Z\n\nIt is sunny.\n")
+}
+
+func TestMarkdownCodeBlock(t *testing.T) {
+	assert.Equal(t, "```go\nx\n```\n", markdownCodeBlock("x\n", "go"))
+	assert.Equal(t, "````\n```x```\n````\n", markdownCodeBlock("```x```", ""))
+}
@@ -0,0 +1,173 @@
+package mistral
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countMessages counts ten tokens per message.
+var countMessages = TokenCounterFunc(func(messages []ChatMessage) (int, error) {
+	return 10 * len(messages), nil
+})
+
+func conversationContents(messages []ChatMessage) []string {
+	contents := make([]string, len(messages))
+	for i, msg := range messages {
+		contents[i] = msg.Content
+	}
+	return contents
+}
+
+func historyContents(history []ConversationMessage) []string {
+	contents := make([]string, len(history))
+	for i, msg := range history {
+		contents[i] = msg.Message.Content
+	}
+	return contents
+}
+
+func TestConversationEvictsWholeTurns(t *testing.T) {
+	call := ToolCall{Id: "call1", Type: ToolTypeFunction, Function: FunctionCall{Name: "get_weather", Arguments: `{}`}}
+	c := NewConversation(&ConversationOptions{MaxPromptTokens: 50, TokenCounter: countMessages})
+	c.Append(
+		SystemMessage("system"),
+		UserMessage("q1"),
+		ChatMessage{Role: RoleAssistant, ToolCalls: []ToolCall{call}},
+		ChatMessage{Role: RoleTool, Content: "sunny", ToolCallId: call.Id},
+		AssistantMessage("a1"),
+		UserMessage("q2"),
+		AssistantMessage("a2"),
+		UserMessage("q3"),
+	)
+
+	assert.NoError(t, c.Fit(context.Background()))
+	// The first turn, including its tool call and result, is evicted as a whole.
+	assert.Equal(t, []string{"system", "q2", "a2", "q3"}, conversationContents(c.Messages()))
+	assert.NoError(t, ValidateToolResults(c.Messages()))
+	assert.Empty(t, c.Summary())
+	assert.Len(t, c.History(), 4)
+}
+
+func TestConversationSummarizes(t *testing.T) {
+	var summarized [][]string
+	summarizer := SummarizerFunc(func(ctx context.Context, summary string, messages []ChatMessage) (string, error) {
+		summarized = append(summarized, conversationContents(messages))
+		return strings.TrimSpace(summary + " " + strings.Join(conversationContents(messages), ",")), nil
+	})
+	c := NewConversation(&ConversationOptions{MaxPromptTokens: 40, TokenCounter: countMessages, Summarizer: summarizer})
+
+	c.Append(SystemMessage("system"), UserMessage("q1"), AssistantMessage("a1"), UserMessage("q2"), AssistantMessage("a2"))
+	assert.NoError(t, c.Fit(context.Background()))
+	assert.Equal(t, []string{"system", "q2", "a2"}, historyContents(c.History()))
+	assert.Equal(t, "q1,a1", c.Summary())
+
+	messages := c.Messages()
+	assert.Equal(t, RoleSystem, messages[1].Role)
+	assert.Equal(t, "Summary of the earlier conversation:\nq1,a1", messages[1].Content)
+	assert.Equal(t, []string{"q2", "a2"}, conversationContents(messages[2:]))
+
+	c.Append(UserMessage("q3"), AssistantMessage("a3"))
+	assert.NoError(t, c.Fit(context.Background()))
+	assert.Equal(t, "q1,a1 q2,a2", c.Summary())
+	assert.Equal(t, [][]string{{"q1", "a1"}, {"q2", "a2"}}, summarized)
+}
+
+func TestConversationSummarizesUnlocked(t *testing.T) {
+	var c *Conversation
+	summarizer := SummarizerFunc(func(ctx context.Context, summary string, messages []ChatMessage) (string, error) {
+		if summary == "" {
+			appended := make(chan struct{})
+			go func() {
+				c.Append(UserMessage("q3"))
+				close(appended)
+			}()
+			select {
+			case <-appended:
+			case <-time.After(time.Second):
+				t.Error("Append blocked while summarizing")
+			}
+		}
+		return strings.TrimSpace(summary + " " + strings.Join(conversationContents(messages), ",")), nil
+	})
+	c = NewConversation(&ConversationOptions{MaxPromptTokens: 40, TokenCounter: countMessages, Summarizer: summarizer})
+
+	c.Append(SystemMessage("system"), UserMessage("q1"), AssistantMessage("a1"), UserMessage("q2"), AssistantMessage("a2"))
+	assert.NoError(t, c.Fit(context.Background()))
+	// The message appended while summarizing is kept, and the turn it pushed over the budget is summarized too.
+	assert.Equal(t, []string{"system", "q3"}, historyContents(c.History()))
+	assert.Equal(t, "q1,a1 q2,a2", c.Summary())
+}
+
+func TestConversationTooLong(t *testing.T) {
+	c := NewConversation(&ConversationOptions{MaxPromptTokens: 10, TokenCounter: countMessages})
+	c.Append(SystemMessage("system"), UserMessage("q1"), AssistantMessage("a1"), UserMessage("q2"))
+
+	err := c.Fit(context.Background())
+	assert.True(t, errors.Is(err, ErrConversationTooLong))
+	// The conversation is left unchanged.
+	assert.Len(t, c.History(), 4)
+}
+
+func TestConversationSummarizerError(t *testing.T) {
+	summarizer := SummarizerFunc(func(ctx context.Context, summary string, messages []ChatMessage) (string, error) {
+		return "", errors.New("unavailable")
+	})
+	c := NewConversation(&ConversationOptions{MaxPromptTokens: 20, TokenCounter: countMessages, Summarizer: summarizer})
+	c.Append(UserMessage("q1"), AssistantMessage("a1"), UserMessage("q2"))
+
+	assert.ErrorContains(t, c.Fit(context.Background()), "unavailable")
+	assert.Len(t, c.History(), 3)
+}
+
+func TestConversationChat(t *testing.T) {
+	srv := newScriptedServer(t,
+		answerResponse("The summary."),
+		answerResponse("Hello again."),
+	)
+	client := srv.Client()
+
+	c := NewConversation(&ConversationOptions{
+		Model:        ModelMistralSmallLatest,
+		TokenCounter: countMessages,
+		Summarizer:   &ClientSummarizer{Client: client, Model: ModelMistralSmallLatest},
+	})
+	c.Append(SystemMessage("system"), UserMessage("q1"), AssistantMessage("a1"), UserMessage("q2"))
+
+	// With a context window of 131072 tokens, reserving all but 30 for the completion leaves room for 3 messages.
+	res, err := c.Chat(context.Background(), client, &ChatRequestParams{MaxTokens: Ptr(131072 - 30)})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello again.", res.Choices[0].Message.Content)
+
+	if assert.Len(t, srv.Requests(), 2) {
+		summaryRequest := srv.Requests()[0].Messages()
+		assert.Equal(t, DefaultSummaryPrompt, summaryRequest[0].Content)
+		assert.Equal(t, "New messages:\nuser: q1\nassistant: a1\n", summaryRequest[1].Content)
+
+		assert.Equal(t, []string{"system", "Summary of the earlier conversation:\nThe summary.", "q2"}, conversationContents(srv.Requests()[1].Messages()))
+	}
+	assert.Equal(t, []string{"system", "q2", "Hello again."}, historyContents(c.History()))
+}
+
+func TestEstimateTokens(t *testing.T) {
+	n, err := EstimateTokens(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = EstimateTokens([]ChatMessage{UserMessage(strings.Repeat("a", 400))})
+	assert.NoError(t, err)
+	assert.Equal(t, 105, n)
+}
+
+func TestContextWindow(t *testing.T) {
+	n, ok := ContextWindow(ModelOpenMixtral8x22b)
+	assert.True(t, ok)
+	assert.Equal(t, 65536, n)
+
+	_, ok = ContextWindow("unknown")
+	assert.False(t, ok)
+}
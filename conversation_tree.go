@@ -0,0 +1,202 @@
+package mistral
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ConversationNode is a message in a ConversationTree. Its fields are set when the node is created and must not be
+// modified.
+type ConversationNode struct {
+	ID        string
+	Message   ChatMessage
+	Params    *ChatRequestParams // The parameters the message was generated with; nil for messages that were added.
+	Usage     UsageInfo          // The usage of the request that generated the message.
+	CreatedAt time.Time
+
+	tree     *ConversationTree
+	parent   *ConversationNode
+	children []*ConversationNode
+}
+
+// Parent returns the message the node follows, or nil for a root.
+func (n *ConversationNode) Parent() *ConversationNode {
+	return n.parent
+}
+
+// Children returns the alternative messages that follow the node, oldest first.
+func (n *ConversationNode) Children() []*ConversationNode {
+	n.tree.mu.Lock()
+	defer n.tree.mu.Unlock()
+	return append([]*ConversationNode(nil), n.children...)
+}
+
+// Path returns the nodes from the root of the tree to the node, inclusive.
+func (n *ConversationNode) Path() []*ConversationNode {
+	var path []*ConversationNode
+	for ; n != nil; n = n.parent {
+		path = append(path, n)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// Messages returns the messages on the path from the root of the tree to the node, ready to be sent with Chat or
+// ChatStream.
+func (n *ConversationNode) Messages() []ChatMessage {
+	path := n.Path()
+	messages := make([]ChatMessage, len(path))
+	for i, node := range path {
+		messages[i] = node.Message
+	}
+	return messages
+}
+
+// ConversationTree is a conversation in which every message can have several alternative continuations, such as an
+// edited user message or a regenerated answer. Any path from a root to a node is a linear conversation that can be
+// sent with Chat or ChatStream.
+//
+// A ConversationTree is safe for concurrent use.
+type ConversationTree struct {
+	mu     sync.Mutex
+	model  string
+	roots  []*ConversationNode
+	nodes  map[string]*ConversationNode
+	nextID int
+}
+
+// NewConversationTree creates an empty tree whose completions are requested from model.
+func NewConversationTree(model string) *ConversationTree {
+	return &ConversationTree{model: model, nodes: map[string]*ConversationNode{}}
+}
+
+// Model returns the model completions are requested from.
+func (t *ConversationTree) Model() string {
+	return t.model
+}
+
+// Roots returns the alternative first messages of the tree, oldest first.
+func (t *ConversationTree) Roots() []*ConversationNode {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*ConversationNode(nil), t.roots...)
+}
+
+// Node returns the node with the given id, or nil if the tree has no such node.
+func (t *ConversationTree) Node(id string) *ConversationNode {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.nodes[id]
+}
+
+// Append adds messages as a chain after parent, or as a new root if parent is nil, and returns the node of the last
+// message.
+func (t *ConversationTree) Append(parent *ConversationNode, messages ...ChatMessage) (*ConversationNode, error) {
+	if len(messages) == 0 {
+		return nil, errors.New("no messages to append")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.check(parent); err != nil {
+		return nil, err
+	}
+	node := parent
+	for _, msg := range messages {
+		node = t.add(node, &ConversationNode{Message: msg})
+	}
+	return node, nil
+}
+
+// Fork adds msg as an alternative to node, such as an edited user message, and returns the new node. The new node
+// follows the same parent as node and starts a branch without node's descendants.
+func (t *ConversationTree) Fork(node *ConversationNode, msg ChatMessage) (*ConversationNode, error) {
+	if node == nil {
+		return nil, errors.New("cannot fork a nil node")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.check(node); err != nil {
+		return nil, err
+	}
+	return t.add(node.parent, &ConversationNode{Message: msg}), nil
+}
+
+// Chat sends the path ending at parent to the model and adds the answer as a new child of parent.
+// A nil params uses the defaults.
+func (t *ConversationTree) Chat(ctx context.Context, client *MistralClient, parent *ConversationNode, params *ChatRequestParams) (*ConversationNode, error) {
+	if parent == nil {
+		return nil, errors.New("cannot chat without messages")
+	}
+	if t.model == "" {
+		return nil, errors.New("conversation tree has no model")
+	}
+
+	t.mu.Lock()
+	err := t.check(parent)
+	t.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.ChatContext(ctx, t.model, parent.Messages(), params)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Choices) == 0 {
+		return nil, errors.New("response has no choices")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.add(parent, &ConversationNode{Message: res.Choices[0].Message, Params: params, Usage: res.Usage}), nil
+}
+
+// Regenerate requests a new answer in place of the assistant message node and adds it as an alternative to node.
+// A nil params reuses the parameters node was generated with.
+func (t *ConversationTree) Regenerate(ctx context.Context, client *MistralClient, node *ConversationNode, params *ChatRequestParams) (*ConversationNode, error) {
+	if node == nil {
+		return nil, errors.New("cannot regenerate a nil node")
+	}
+	if node.Message.Role != RoleAssistant {
+		return nil, fmt.Errorf("cannot regenerate a %s message", node.Message.Role)
+	}
+	if node.parent == nil {
+		return nil, errors.New("cannot regenerate a message without a prompt")
+	}
+	if params == nil {
+		params = node.Params
+	}
+	return t.Chat(ctx, client, node.parent, params)
+}
+
+// check reports an error if node belongs to another tree. A nil node is valid. It must be called with t.mu held.
+func (t *ConversationTree) check(node *ConversationNode) error {
+	if node != nil && node.tree != t {
+		return fmt.Errorf("node %s does not belong to this conversation tree", node.ID)
+	}
+	return nil
+}
+
+// add links node after parent. It must be called with t.mu held.
+func (t *ConversationTree) add(parent *ConversationNode, node *ConversationNode) *ConversationNode {
+	t.nextID++
+	node.ID = "node-" + strconv.Itoa(t.nextID)
+	node.CreatedAt = time.Now()
+	node.tree = t
+	node.parent = parent
+	if parent == nil {
+		t.roots = append(t.roots, node)
+	} else {
+		parent.children = append(parent.children, node)
+	}
+	t.nodes[node.ID] = node
+	return node
+}
@@ -0,0 +1,89 @@
+package mistral
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func nodeIDs(nodes []*ConversationNode) []string {
+	ids := make([]string, len(nodes))
+	for i, node := range nodes {
+		ids[i] = node.ID
+	}
+	return ids
+}
+
+func TestConversationTreeFork(t *testing.T) {
+	tree := NewConversationTree(ModelMistralSmallLatest)
+	a1, err := tree.Append(nil, SystemMessage("system"), UserMessage("q1"), AssistantMessage("a1"))
+	assert.NoError(t, err)
+	q2, err := tree.Append(a1, UserMessage("q2"))
+	assert.NoError(t, err)
+	q1 := a1.Parent()
+
+	edited, err := tree.Fork(q1, UserMessage("q1 edited"))
+	assert.NoError(t, err)
+	assert.Equal(t, q1.Parent(), edited.Parent())
+	assert.Equal(t, []string{q1.ID, edited.ID}, nodeIDs(q1.Parent().Children()))
+	assert.Empty(t, edited.Children())
+
+	assert.Equal(t, []string{"system", "q1", "a1", "q2"}, conversationContents(q2.Messages()))
+	assert.Equal(t, []string{"system", "q1 edited"}, conversationContents(edited.Messages()))
+	assert.Len(t, q2.Path(), 4)
+	assert.Equal(t, q2, tree.Node(q2.ID))
+
+	// Forking a root adds a new root.
+	root, err := tree.Fork(tree.Roots()[0], SystemMessage("other system"))
+	assert.NoError(t, err)
+	assert.Nil(t, root.Parent())
+	assert.Len(t, tree.Roots(), 2)
+
+	_, err = tree.Append(nil)
+	assert.Error(t, err)
+	_, err = NewConversationTree(ModelMistralSmallLatest).Append(q2, UserMessage("q3"))
+	assert.ErrorContains(t, err, "does not belong")
+}
+
+func TestConversationTreeRegenerate(t *testing.T) {
+	srv := newScriptedServer(t,
+		answerResponse("first"),
+		answerResponse("second"),
+		answerResponse("third"),
+	)
+	client := srv.Client()
+	ctx := context.Background()
+
+	tree := NewConversationTree(ModelMistralSmallLatest)
+	q1, err := tree.Append(nil, UserMessage("q1"))
+	assert.NoError(t, err)
+
+	params := &ChatRequestParams{Temperature: Ptr(0.7)}
+	first, err := tree.Chat(ctx, client, q1, params)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", first.Message.Content)
+	assert.Equal(t, params, first.Params)
+	assert.Equal(t, 25, first.Usage.TotalTokens)
+
+	// Without params the answer is regenerated with the parameters of the original.
+	second, err := tree.Regenerate(ctx, client, first, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", second.Message.Content)
+	assert.Equal(t, params, second.Params)
+
+	newParams := &ChatRequestParams{Temperature: Ptr(1.0)}
+	third, err := tree.Regenerate(ctx, client, second, newParams)
+	assert.NoError(t, err)
+	assert.Equal(t, newParams, third.Params)
+
+	assert.Equal(t, []string{first.ID, second.ID, third.ID}, nodeIDs(q1.Children()))
+	if assert.Len(t, srv.Requests(), 3) {
+		for _, request := range srv.Requests() {
+			assert.Equal(t, []string{"q1"}, conversationContents(request.Messages()))
+		}
+	}
+
+	_, err = tree.Regenerate(ctx, client, q1, nil)
+	assert.ErrorContains(t, err, "cannot regenerate a user message")
+}
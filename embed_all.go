@@ -0,0 +1,221 @@
+package mistral
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+const (
+	DefaultEmbedBatchSize    = 128
+	DefaultEmbedBatchTokens  = 16384
+	DefaultEmbedConcurrency  = 4
+	DefaultEmbedBatchRetries = 2
+)
+
+// EmbedAllOptions configures EmbedAll.
+type EmbedAllOptions struct {
+	MaxBatchSize   int                          // Maximum number of inputs per request. Defaults to DefaultEmbedBatchSize.
+	MaxBatchTokens int                          // Maximum estimated tokens per request. Defaults to DefaultEmbedBatchTokens. Longer inputs are sent on their own.
+	MaxConcurrency int                          // Maximum number of requests in flight. Defaults to DefaultEmbedConcurrency.
+	CountTokens    func(input string) int       // Estimates the tokens of an input. Defaults to one token per four bytes.
+	OnProgress     func(progress EmbedProgress) // Called after every completed batch. Calls are never concurrent.
+
+	// MaxBatchRetries bounds how many times a failed batch is sent again, on top of the retries of the client's
+	// retry policy. Defaults to DefaultEmbedBatchRetries; a negative value disables batch retries. Errors caused by
+	// the request itself, such as an input that is too long, are not retried.
+	MaxBatchRetries int
+}
+
+// EmbedProgress reports the progress of EmbedAll.
+type EmbedProgress struct {
+	CompletedBatches int
+	TotalBatches     int
+	CompletedInputs  int
+	TotalInputs      int
+	Usage            UsageInfo // The usage summed over the completed batches.
+}
+
+// embeddingBatch is the range [start, end) of the inputs sent in one request.
+type embeddingBatch struct {
+	start, end int
+}
+
+// EmbedAll embeds any number of inputs by splitting params.Input into batches that respect the request limits and
+// sending them concurrently. Failed batches are retried on their own. The embeddings are returned in input order with
+// Index set to the position of their input, and Usage is summed over every batch. The values are decoded as by
+// CreateEmbeddings. If a batch fails for good the remaining batches are cancelled and the error is returned.
+func (c *MistralClient) EmbedAll(ctx context.Context, params *EmbeddingsRequestParams, opts *EmbedAllOptions) (*EmbeddingResponse, error) {
+	if opts == nil {
+		opts = &EmbedAllOptions{}
+	}
+	batches := embeddingBatches(params.Input, opts)
+
+	result := &EmbeddingResponse{Object: "list", Model: params.Model, Data: make([]EmbeddingObject, len(params.Input))}
+	progress := EmbedProgress{TotalBatches: len(batches), TotalInputs: len(params.Input)}
+	if len(batches) == 0 {
+		return result, nil
+	}
+
+	concurrency := opts.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultEmbedConcurrency
+	}
+	if concurrency > len(batches) {
+		concurrency = len(batches)
+	}
+
+	batchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var firstErr error
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, batch := range batches {
+		sem <- struct{}{}
+		if batchCtx.Err() != nil {
+			<-sem
+			break
+		}
+
+		wg.Add(1)
+		go func(batch embeddingBatch) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			res, err := c.embedBatch(batchCtx, params, batch, opts)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+
+			for _, embedding := range res.Data {
+				embedding.Index += batch.start
+				result.Data[embedding.Index] = embedding
+			}
+			if result.ID == "" {
+				result.ID = res.ID
+				result.Model = res.Model
+			}
+			result.Usage.PromptTokens += res.Usage.PromptTokens
+			result.Usage.CompletionTokens += res.Usage.CompletionTokens
+			result.Usage.TotalTokens += res.Usage.TotalTokens
+
+			progress.CompletedBatches++
+			progress.CompletedInputs += batch.end - batch.start
+			progress.Usage = result.Usage
+			if opts.OnProgress != nil {
+				opts.OnProgress(progress)
+			}
+		}(batch)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// embedBatch sends one batch, retrying it according to opts, and checks that every input got an embedding.
+func (c *MistralClient) embedBatch(ctx context.Context, params *EmbeddingsRequestParams, batch embeddingBatch, opts *EmbedAllOptions) (*EmbeddingResponse, error) {
+	batchParams := *params
+	batchParams.Input = params.Input[batch.start:batch.end]
+
+	retries := opts.MaxBatchRetries
+	if retries == 0 {
+		retries = DefaultEmbedBatchRetries
+	}
+	for attempt := 1; ; attempt++ {
+		res, err := c.CreateEmbeddingsContext(ctx, &batchParams)
+		if err == nil {
+			err = checkBatchEmbeddings(res, len(batchParams.Input))
+		}
+		if err == nil {
+			return res, nil
+		}
+		if attempt > retries || !isRetryableBatchError(ctx, err) {
+			return nil, fmt.Errorf("error embedding inputs %d to %d: %w", batch.start, batch.end-1, err)
+		}
+		if err := sleepContext(ctx, c.retryPolicy.delay(attempt, nil)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// checkBatchEmbeddings reports an error unless res holds exactly one embedding for each of the n inputs.
+func checkBatchEmbeddings(res *EmbeddingResponse, n int) error {
+	if len(res.Data) != n {
+		return fmt.Errorf("got %d embeddings for %d inputs", len(res.Data), n)
+	}
+	seen := make([]bool, n)
+	for _, embedding := range res.Data {
+		if embedding.Index < 0 || embedding.Index >= n || seen[embedding.Index] {
+			return fmt.Errorf("invalid embedding index %d", embedding.Index)
+		}
+		seen[embedding.Index] = true
+	}
+	return nil
+}
+
+// isRetryableBatchError reports whether sending a failed batch again may succeed. Client errors other than rate
+// limits are caused by the request and fail again.
+func isRetryableBatchError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *MistralAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatus == http.StatusTooManyRequests || apiErr.HTTPStatus >= 500
+	}
+	return true
+}
+
+// embeddingBatches splits the inputs into consecutive batches of at most opts.MaxBatchSize inputs and
+// opts.MaxBatchTokens estimated tokens.
+func embeddingBatches(input []string, opts *EmbedAllOptions) []embeddingBatch {
+	maxSize := opts.MaxBatchSize
+	if maxSize <= 0 {
+		maxSize = DefaultEmbedBatchSize
+	}
+	maxTokens := opts.MaxBatchTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultEmbedBatchTokens
+	}
+	countTokens := opts.CountTokens
+	if countTokens == nil {
+		countTokens = estimateInputTokens
+	}
+
+	var batches []embeddingBatch
+	start, tokens := 0, 0
+	for i, s := range input {
+		n := countTokens(s)
+		if i > start && (i-start >= maxSize || tokens+n > maxTokens) {
+			batches = append(batches, embeddingBatch{start, i})
+			start, tokens = i, 0
+		}
+		tokens += n
+	}
+	if start < len(input) {
+		batches = append(batches, embeddingBatch{start, len(input)})
+	}
+	return batches
+}
+
+// estimateInputTokens approximates the tokens of an input at one token per four bytes, like EstimateTokens.
+func estimateInputTokens(input string) int {
+	return 1 + (len(input)+3)/4
+}
@@ -0,0 +1,141 @@
+package mistral
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newEmbedAllServer answers embeddings requests with one-dimensional embeddings holding the number in each input, in
+// reverse order so the client has to sort them by index. handle can fail a request by returning a status code.
+func newEmbedAllServer(t *testing.T, handle func(input []string) int) *testServer {
+	t.Helper()
+	return newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input []string `json:"input"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		if handle != nil {
+			if status := handle(body.Input); status != 0 {
+				w.WriteHeader(status)
+				w.Write([]byte(`{"message":"failed"}`))
+				return
+			}
+		}
+
+		data := make([]map[string]interface{}, len(body.Input))
+		for i, input := range body.Input {
+			n, err := strconv.Atoi(strings.TrimPrefix(input, "input "))
+			assert.NoError(t, err)
+			data[len(data)-1-i] = map[string]interface{}{"object": "embedding", "embedding": []float64{float64(n)}, "index": i}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":    "embd",
+			"model": "mistral-embed",
+			"data":  data,
+			"usage": map[string]int{"prompt_tokens": len(body.Input), "total_tokens": len(body.Input)},
+		})
+	})
+}
+
+func embedAllInputs(n int) []string {
+	input := make([]string, n)
+	for i := range input {
+		input[i] = fmt.Sprintf("input %d", i)
+	}
+	return input
+}
+
+func newEmbedAllClient(srv *testServer) *MistralClient {
+	return srv.Client(WithRetryPolicy(RetryPolicy{MaxAttempts: 1, BaseDelay: time.Millisecond}))
+}
+
+func TestEmbedAll(t *testing.T) {
+	var inFlight, maxInFlight int32
+	srv := newEmbedAllServer(t, func(input []string) int {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			prev := atomic.LoadInt32(&maxInFlight)
+			if n <= prev || atomic.CompareAndSwapInt32(&maxInFlight, prev, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return 0
+	})
+	client := newEmbedAllClient(srv)
+
+	var progress []EmbedProgress
+	res, err := client.EmbedAll(context.Background(), &EmbeddingsRequestParams{Model: "mistral-embed", Input: embedAllInputs(25)}, &EmbedAllOptions{
+		MaxBatchSize:   4,
+		MaxConcurrency: 2,
+		OnProgress:     func(p EmbedProgress) { progress = append(progress, p) },
+	})
+	assert.NoError(t, err)
+
+	assert.Len(t, srv.Requests(), 7)
+	assert.LessOrEqual(t, maxInFlight, int32(2))
+	if assert.Len(t, res.Data, 25) {
+		for i, embedding := range res.Data {
+			assert.Equal(t, i, embedding.Index)
+			assert.Equal(t, []float32{float32(i)}, embedding.Float32())
+		}
+	}
+	assert.Equal(t, 25, res.Usage.TotalTokens)
+	if assert.Len(t, progress, 7) {
+		last := progress[6]
+		assert.Equal(t, EmbedProgress{CompletedBatches: 7, TotalBatches: 7, CompletedInputs: 25, TotalInputs: 25, Usage: res.Usage}, last)
+	}
+}
+
+func TestEmbedAllRetriesFailedBatch(t *testing.T) {
+	var failed int32
+	srv := newEmbedAllServer(t, func(input []string) int {
+		if input[0] == "input 2" && atomic.AddInt32(&failed, 1) == 1 {
+			return http.StatusServiceUnavailable
+		}
+		return 0
+	})
+	client := newEmbedAllClient(srv)
+
+	res, err := client.EmbedAll(context.Background(), &EmbeddingsRequestParams{Model: "mistral-embed", Input: embedAllInputs(6)}, &EmbedAllOptions{MaxBatchSize: 2})
+	assert.NoError(t, err)
+	assert.Len(t, res.Data, 6)
+	// Only the failed batch is sent again.
+	assert.Len(t, srv.Requests(), 4)
+}
+
+func TestEmbedAllFails(t *testing.T) {
+	srv := newEmbedAllServer(t, func(input []string) int {
+		if input[0] == "input 0" {
+			return http.StatusBadRequest
+		}
+		return 0
+	})
+	client := newEmbedAllClient(srv)
+
+	_, err := client.EmbedAll(context.Background(), &EmbeddingsRequestParams{Model: "mistral-embed", Input: embedAllInputs(2)}, &EmbedAllOptions{MaxBatchSize: 1, MaxConcurrency: 1})
+	assert.ErrorContains(t, err, "error embedding inputs 0 to 0")
+	// Client errors are not retried and stop the remaining batches.
+	assert.Len(t, srv.Requests(), 1)
+}
+
+func TestEmbeddingBatches(t *testing.T) {
+	input := []string{"aaaa", "aaaa", strings.Repeat("a", 40), "aaaa", "aaaa", "aaaa"}
+	batches := embeddingBatches(input, &EmbedAllOptions{MaxBatchSize: 2, MaxBatchTokens: 6})
+	// Inputs count 2 tokens, except the long one which counts 11 and is sent on its own.
+	assert.Equal(t, []embeddingBatch{{0, 2}, {2, 3}, {3, 5}, {5, 6}}, batches)
+
+	assert.Empty(t, embeddingBatches(nil, &EmbedAllOptions{}))
+	assert.Equal(t, []embeddingBatch{{0, 3}}, embeddingBatches([]string{"a", "b", "c"}, &EmbedAllOptions{}))
+}
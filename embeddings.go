@@ -1,15 +1,123 @@
 package mistral
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
 )
 
+// EmbeddingEncodingFormat the format embeddings are transferred in
+type EmbeddingEncodingFormat string
+
+const (
+	EmbeddingEncodingFloat  EmbeddingEncodingFormat = "float"
+	EmbeddingEncodingBase64 EmbeddingEncodingFormat = "base64"
+)
+
+// EmbeddingDtype the data type of the values of an embedding
+type EmbeddingDtype string
+
+const (
+	EmbeddingDtypeFloat   EmbeddingDtype = "float"
+	EmbeddingDtypeInt8    EmbeddingDtype = "int8"
+	EmbeddingDtypeUint8   EmbeddingDtype = "uint8"
+	EmbeddingDtypeBinary  EmbeddingDtype = "binary"  // Bits packed eight per value into int8 values.
+	EmbeddingDtypeUbinary EmbeddingDtype = "ubinary" // Bits packed eight per value into uint8 values.
+)
+
+// EmbeddingsRequestParams represents the parameters for the CreateEmbeddings method of MistralClient.
+type EmbeddingsRequestParams struct {
+	Model           string                  `json:"model"`
+	Input           []string                `json:"input"`
+	EncodingFormat  EmbeddingEncodingFormat `json:"encoding_format,omitempty"`  // How the embeddings are transferred. Defaults to EmbeddingEncodingFloat; base64 is more compact.
+	OutputDimension *int                    `json:"output_dimension,omitempty"` // The size of the embeddings, for models that support shorter ones. Nil uses the model's size.
+	OutputDtype     EmbeddingDtype          `json:"output_dtype,omitempty"`     // The data type of the embeddings. Defaults to EmbeddingDtypeFloat.
+}
+
 // EmbeddingObject represents an embedding object in the response.
+// Embeddings returns float64 values in Embedding. CreateEmbeddings decodes the values into the type selected by
+// EmbeddingsRequestParams.OutputDtype instead, which are read with Float32, Int8, Uint8 or Bits.
+//
+// Embeddings encode to JSON with their values in the "embedding" array whatever their data type, and with a "dtype"
+// field for data types other than float, so they can be stored and decoded again.
 type EmbeddingObject struct {
 	Object    string    `json:"object"`
 	Embedding []float64 `json:"embedding"`
 	Index     int       `json:"index"`
+
+	dtype       EmbeddingDtype
+	floatValues []float32
+	int8Values  []int8
+	uint8Values []uint8
+}
+
+// Dtype returns the data type of the embedding's values.
+func (e *EmbeddingObject) Dtype() EmbeddingDtype {
+	if e.dtype == "" {
+		return EmbeddingDtypeFloat
+	}
+	return e.dtype
+}
+
+// Dimension returns the number of dimensions of the embedding. Binary embeddings pack eight dimensions per value.
+func (e *EmbeddingObject) Dimension() int {
+	switch e.Dtype() {
+	case EmbeddingDtypeInt8:
+		return len(e.int8Values)
+	case EmbeddingDtypeUint8:
+		return len(e.uint8Values)
+	case EmbeddingDtypeBinary:
+		return 8 * len(e.int8Values)
+	case EmbeddingDtypeUbinary:
+		return 8 * len(e.uint8Values)
+	}
+	if e.floatValues != nil {
+		return len(e.floatValues)
+	}
+	return len(e.Embedding)
+}
+
+// Float32 returns the values of a float embedding, or nil for other data types.
+func (e *EmbeddingObject) Float32() []float32 {
+	if e.floatValues != nil || e.Dtype() != EmbeddingDtypeFloat {
+		return e.floatValues
+	}
+	values := make([]float32, len(e.Embedding))
+	for i, v := range e.Embedding {
+		values[i] = float32(v)
+	}
+	return values
+}
+
+// Int8 returns the values of an int8 or binary embedding, or nil for other data types.
+func (e *EmbeddingObject) Int8() []int8 {
+	return e.int8Values
+}
+
+// Uint8 returns the values of a uint8 or ubinary embedding, or nil for other data types.
+func (e *EmbeddingObject) Uint8() []uint8 {
+	return e.uint8Values
+}
+
+// Bits returns the packed bits of a binary or ubinary embedding, most significant bit first, or nil for other data
+// types. Binary values are offset by 128 so both data types return the same bits.
+func (e *EmbeddingObject) Bits() []byte {
+	switch e.Dtype() {
+	case EmbeddingDtypeUbinary:
+		return e.uint8Values
+	case EmbeddingDtypeBinary:
+		bits := make([]byte, len(e.int8Values))
+		for i, v := range e.int8Values {
+			bits[i] = byte(v) ^ 0x80
+		}
+		return bits
+	}
+	return nil
 }
 
 // EmbeddingResponse represents the response from the embeddings endpoint.
@@ -21,27 +129,270 @@ type EmbeddingResponse struct {
 	Usage  UsageInfo         `json:"usage"`
 }
 
+// Embeddings returns the embeddings of the given input texts.
 func (c *MistralClient) Embeddings(model string, input []string) (*EmbeddingResponse, error) {
+	return c.EmbeddingsContext(context.Background(), model, input)
+}
+
+// EmbeddingsContext is like Embeddings but the request, including any retries, is bound to the given context.
+func (c *MistralClient) EmbeddingsContext(ctx context.Context, model string, input []string) (*EmbeddingResponse, error) {
 	requestData := map[string]interface{}{
 		"model": model,
 		"input": input,
 	}
 
-	response, err := c.request(http.MethodPost, requestData, "v1/embeddings", false, nil)
+	respBody, err := c.embeddingsRequest(ctx, requestData)
 	if err != nil {
 		return nil, err
 	}
+	defer respBody.Close()
 
-	respData, ok := response.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid response type: %T", response)
+	var embeddingResponse EmbeddingResponse
+	if err := json.NewDecoder(respBody).Decode(&embeddingResponse); err != nil {
+		return nil, fmt.Errorf("error decoding embeddings: %w", err)
 	}
 
-	var embeddingResponse EmbeddingResponse
-	err = mapToStruct(respData, &embeddingResponse)
+	return &embeddingResponse, nil
+}
+
+// CreateEmbeddings returns the embeddings of params.Input with the requested dimension, data type and encoding.
+// The values are decoded straight into the requested data type; read them with the accessors of EmbeddingObject.
+func (c *MistralClient) CreateEmbeddings(params *EmbeddingsRequestParams) (*EmbeddingResponse, error) {
+	return c.CreateEmbeddingsContext(context.Background(), params)
+}
+
+// CreateEmbeddingsContext is like CreateEmbeddings but the request, including any retries, is bound to the given
+// context.
+func (c *MistralClient) CreateEmbeddingsContext(ctx context.Context, params *EmbeddingsRequestParams) (*EmbeddingResponse, error) {
+	respBody, err := c.embeddingsRequest(ctx, embeddingsRequestData(params))
 	if err != nil {
 		return nil, err
 	}
+	defer respBody.Close()
 
-	return &embeddingResponse, nil
+	dtype := params.OutputDtype
+	if dtype == "" {
+		dtype = EmbeddingDtypeFloat
+	}
+	return decodeEmbeddingResponse(respBody, dtype)
+}
+
+// decodeEmbeddingResponse decodes an embeddings response one embedding at a time, decoding the values of each
+// straight into dtype, so only the text of the embedding being decoded is buffered.
+func decodeEmbeddingResponse(r io.Reader, dtype EmbeddingDtype) (*EmbeddingResponse, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, fmt.Errorf("error decoding embeddings: %w", err)
+	}
+
+	var response EmbeddingResponse
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding embeddings: %w", err)
+		}
+		switch key {
+		case "data":
+			err = decodeEmbeddingList(dec, dtype, &response.Data)
+		case "id":
+			err = dec.Decode(&response.ID)
+		case "object":
+			err = dec.Decode(&response.Object)
+		case "model":
+			err = dec.Decode(&response.Model)
+		case "usage":
+			err = dec.Decode(&response.Usage)
+		default:
+			err = dec.Decode(new(json.RawMessage))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error decoding embeddings: %w", err)
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, fmt.Errorf("error decoding embeddings: %w", err)
+	}
+	return &response, nil
+}
+
+// decodeEmbeddingList decodes the "data" array of an embeddings response into list.
+func decodeEmbeddingList(dec *json.Decoder, dtype EmbeddingDtype, list *[]EmbeddingObject) error {
+	token, err := dec.Token()
+	if err != nil || token == nil {
+		return err
+	}
+	if token != json.Delim('[') {
+		return fmt.Errorf("expected an array of embeddings, found %v", token)
+	}
+
+	for i := 0; dec.More(); i++ {
+		embedding := EmbeddingObject{dtype: dtype}
+		if err := dec.Decode(embedding.fields()); err != nil {
+			return fmt.Errorf("embedding %d: %w", i, err)
+		}
+		*list = append(*list, embedding)
+	}
+	return expectDelim(dec, ']')
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, found %v", delim, token)
+	}
+	return nil
+}
+
+func (c *MistralClient) embeddingsRequest(ctx context.Context, requestData map[string]interface{}) (io.ReadCloser, error) {
+	// The body is requested as a stream so it can be decoded without going through a generic map.
+	response, err := c.request(ctx, http.MethodPost, requestData, "v1/embeddings", true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, ok := response.(io.ReadCloser)
+	if !ok {
+		return nil, fmt.Errorf("invalid response type: %T", response)
+	}
+	return respBody, nil
+}
+
+// embeddingsRequestData builds the request body for the embeddings endpoint, leaving out optional parameters that are
+// unset.
+func embeddingsRequestData(params *EmbeddingsRequestParams) map[string]interface{} {
+	requestData := map[string]interface{}{
+		"model": params.Model,
+		"input": params.Input,
+	}
+
+	if params.EncodingFormat != "" {
+		requestData["encoding_format"] = params.EncodingFormat
+	}
+	if params.OutputDimension != nil {
+		requestData["output_dimension"] = *params.OutputDimension
+	}
+	if params.OutputDtype != "" {
+		requestData["output_dtype"] = params.OutputDtype
+	}
+
+	return requestData
+}
+
+// MarshalJSON encodes the embedding with its values, see EmbeddingObject.
+func (e EmbeddingObject) MarshalJSON() ([]byte, error) {
+	var values any = e.Embedding
+	switch {
+	case e.floatValues != nil:
+		values = e.floatValues
+	case e.int8Values != nil:
+		values = e.int8Values
+	case e.uint8Values != nil:
+		// A []uint8 would be encoded as a base64 string.
+		ints := make([]int, len(e.uint8Values))
+		for i, v := range e.uint8Values {
+			ints[i] = int(v)
+		}
+		values = ints
+	}
+
+	var dtype EmbeddingDtype
+	if e.Dtype() != EmbeddingDtypeFloat {
+		dtype = e.Dtype()
+	}
+	return json.Marshal(struct {
+		Object    string         `json:"object"`
+		Embedding any            `json:"embedding"`
+		Index     int            `json:"index"`
+		Dtype     EmbeddingDtype `json:"dtype,omitempty"`
+	}{e.Object, values, e.Index, dtype})
+}
+
+// UnmarshalJSON decodes an embedding of the API, or one encoded by MarshalJSON. Float values are decoded into
+// Embedding and the other data types into the values read by their accessors.
+func (e *EmbeddingObject) UnmarshalJSON(data []byte) error {
+	// The data type is read first, since it may follow the values, so they can be decoded straight into it.
+	var header struct {
+		Dtype EmbeddingDtype `json:"dtype"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+
+	*e = EmbeddingObject{}
+	if header.Dtype == "" || header.Dtype == EmbeddingDtypeFloat {
+		type plain EmbeddingObject
+		return json.Unmarshal(data, (*plain)(e))
+	}
+	e.dtype = header.Dtype
+	return json.Unmarshal(data, e.fields())
+}
+
+// fields returns the target to decode an embedding object into e, with its values decoded into the data type of e.
+func (e *EmbeddingObject) fields() any {
+	return &struct {
+		Object    *string         `json:"object"`
+		Embedding embeddingValues `json:"embedding"`
+		Index     *int            `json:"index"`
+	}{&e.Object, embeddingValues{e}, &e.Index}
+}
+
+// embeddingValues decodes the "embedding" field of an embedding object with EmbeddingObject.decode.
+type embeddingValues struct {
+	embedding *EmbeddingObject
+}
+
+func (v embeddingValues) UnmarshalJSON(data []byte) error {
+	return v.embedding.decode(data)
+}
+
+// decode reads the values of the embedding from a JSON array of numbers or a base64 string. Base64 floats are
+// little-endian float32 values; the other data types use one byte per value.
+func (e *EmbeddingObject) decode(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var encoded string
+		if err := json.Unmarshal(data, &encoded); err != nil {
+			return err
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return err
+		}
+		return e.decodeBytes(decoded)
+	}
+
+	switch e.dtype {
+	case EmbeddingDtypeFloat:
+		return json.Unmarshal(data, &e.floatValues)
+	case EmbeddingDtypeInt8, EmbeddingDtypeBinary:
+		return json.Unmarshal(data, &e.int8Values)
+	case EmbeddingDtypeUint8, EmbeddingDtypeUbinary:
+		return json.Unmarshal(data, &e.uint8Values)
+	}
+	return fmt.Errorf("unknown embedding data type %q", e.dtype)
+}
+
+func (e *EmbeddingObject) decodeBytes(data []byte) error {
+	switch e.dtype {
+	case EmbeddingDtypeFloat:
+		if len(data)%4 != 0 {
+			return fmt.Errorf("invalid float32 data of %d bytes", len(data))
+		}
+		e.floatValues = make([]float32, len(data)/4)
+		for i := range e.floatValues {
+			e.floatValues[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+		}
+	case EmbeddingDtypeInt8, EmbeddingDtypeBinary:
+		e.int8Values = make([]int8, len(data))
+		for i, b := range data {
+			e.int8Values[i] = int8(b)
+		}
+	case EmbeddingDtypeUint8, EmbeddingDtypeUbinary:
+		e.uint8Values = data
+	default:
+		return fmt.Errorf("unknown embedding data type %q", e.dtype)
+	}
+	return nil
 }
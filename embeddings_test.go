@@ -1,12 +1,18 @@
 package mistral
 
 import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestEmbeddings(t *testing.T) {
+	skipWithoutAPIKey(t, "MISTRAL_API_KEY")
 	client := NewMistralClientDefault("")
 	res, err := client.Embeddings("mistral-embed", []string{"Embed this sentence.", "As well as this one."})
 	assert.NoError(t, err)
@@ -15,3 +21,162 @@ func TestEmbeddings(t *testing.T) {
 	assert.Equal(t, len(res.Data), 2)
 	assert.Len(t, res.Data[0].Embedding, 1024)
 }
+
+// embeddingsResponse returns the body of an embeddings response holding the given raw embedding values.
+func embeddingsResponse(embeddings ...interface{}) map[string]interface{} {
+	data := make([]map[string]interface{}, len(embeddings))
+	for i, embedding := range embeddings {
+		data[i] = map[string]interface{}{"object": "embedding", "embedding": embedding, "index": i}
+	}
+	return map[string]interface{}{
+		"id":    "embd-1",
+		"model": "mistral-embed",
+		"data":  data,
+		"usage": map[string]int{"prompt_tokens": 4, "total_tokens": 4},
+	}
+}
+
+func TestEmbeddingsDecoding(t *testing.T) {
+	srv := newScriptedServer(t, embeddingsResponse([]float64{0.25, -0.5}))
+	res, err := srv.Client().Embeddings("mistral-embed", []string{"a"})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.25, -0.5}, res.Data[0].Embedding)
+	assert.Equal(t, []float32{0.25, -0.5}, res.Data[0].Float32())
+	assert.Equal(t, EmbeddingDtypeFloat, res.Data[0].Dtype())
+	assert.Equal(t, 2, res.Data[0].Dimension())
+	assert.Equal(t, 4, res.Usage.TotalTokens)
+	request := srv.Requests()[0]
+	assert.Equal(t, "/v1/embeddings", request.Path)
+	assert.Equal(t, map[string]interface{}{"model": "mistral-embed", "input": []interface{}{"a"}}, request.JSON())
+}
+
+func TestCreateEmbeddings(t *testing.T) {
+	floats := make([]byte, 8)
+	binary.LittleEndian.PutUint32(floats, math.Float32bits(0.25))
+	binary.LittleEndian.PutUint32(floats[4:], math.Float32bits(-0.5))
+
+	tests := []struct {
+		name      string
+		params    EmbeddingsRequestParams
+		embedding interface{}
+		check     func(t *testing.T, e *EmbeddingObject)
+	}{
+		{"float", EmbeddingsRequestParams{}, []float64{0.25, -0.5}, func(t *testing.T, e *EmbeddingObject) {
+			assert.Equal(t, []float32{0.25, -0.5}, e.Float32())
+			assert.Nil(t, e.Embedding)
+			assert.Nil(t, e.Int8())
+		}},
+		{"float base64", EmbeddingsRequestParams{EncodingFormat: EmbeddingEncodingBase64}, base64.StdEncoding.EncodeToString(floats), func(t *testing.T, e *EmbeddingObject) {
+			assert.Equal(t, []float32{0.25, -0.5}, e.Float32())
+		}},
+		{"int8", EmbeddingsRequestParams{OutputDtype: EmbeddingDtypeInt8}, []int{-128, 0, 127}, func(t *testing.T, e *EmbeddingObject) {
+			assert.Equal(t, []int8{-128, 0, 127}, e.Int8())
+			assert.Nil(t, e.Float32())
+			assert.Nil(t, e.Bits())
+			assert.Equal(t, 3, e.Dimension())
+		}},
+		{"uint8 base64", EmbeddingsRequestParams{OutputDtype: EmbeddingDtypeUint8, EncodingFormat: EmbeddingEncodingBase64}, base64.StdEncoding.EncodeToString([]byte{0, 200, 255}), func(t *testing.T, e *EmbeddingObject) {
+			assert.Equal(t, []uint8{0, 200, 255}, e.Uint8())
+		}},
+		{"binary", EmbeddingsRequestParams{OutputDtype: EmbeddingDtypeBinary}, []int{-128, 127}, func(t *testing.T, e *EmbeddingObject) {
+			assert.Equal(t, []byte{0x00, 0xff}, e.Bits())
+			assert.Equal(t, 16, e.Dimension())
+		}},
+		{"ubinary", EmbeddingsRequestParams{OutputDtype: EmbeddingDtypeUbinary}, []int{0, 255}, func(t *testing.T, e *EmbeddingObject) {
+			assert.Equal(t, []byte{0x00, 0xff}, e.Bits())
+			assert.Equal(t, []uint8{0, 255}, e.Uint8())
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newScriptedServer(t, embeddingsResponse(tt.embedding))
+
+			params := tt.params
+			params.Model = "mistral-embed"
+			params.Input = []string{"a"}
+			res, err := srv.Client().CreateEmbeddings(&params)
+			assert.NoError(t, err)
+			if assert.Len(t, res.Data, 1) {
+				tt.check(t, &res.Data[0])
+			}
+		})
+	}
+}
+
+func TestDecodeEmbeddingResponse(t *testing.T) {
+	body := `{"data":[{"index":1,"embedding":[1,-1],"object":"embedding","extra":{"a":[1]}}],"extra":[{}],"id":"emb","usage":{"total_tokens":3}}`
+	res, err := decodeEmbeddingResponse(strings.NewReader(body), EmbeddingDtypeInt8)
+	assert.NoError(t, err)
+	assert.Equal(t, "emb", res.ID)
+	assert.Equal(t, 3, res.Usage.TotalTokens)
+	if assert.Len(t, res.Data, 1) {
+		assert.Equal(t, 1, res.Data[0].Index)
+		assert.Equal(t, "embedding", res.Data[0].Object)
+		assert.Equal(t, []int8{1, -1}, res.Data[0].Int8())
+	}
+
+	res, err = decodeEmbeddingResponse(strings.NewReader(`{"id":"emb","data":null}`), EmbeddingDtypeFloat)
+	assert.NoError(t, err)
+	assert.Empty(t, res.Data)
+
+	_, err = decodeEmbeddingResponse(strings.NewReader(`{"data":{}}`), EmbeddingDtypeFloat)
+	assert.Error(t, err)
+	_, err = decodeEmbeddingResponse(strings.NewReader(`{"data":[{"embedding":[0.5]}`), EmbeddingDtypeFloat)
+	assert.Error(t, err)
+}
+
+func TestEmbeddingObjectJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		dtype     EmbeddingDtype
+		embedding interface{}
+		encoded   string
+	}{
+		{"float", EmbeddingDtypeFloat, []float64{0.25, -0.5}, `{"object":"embedding","embedding":[0.25,-0.5],"index":0}`},
+		{"int8", EmbeddingDtypeInt8, []int{-128, 127}, `{"object":"embedding","embedding":[-128,127],"index":0,"dtype":"int8"}`},
+		{"ubinary", EmbeddingDtypeUbinary, []int{0, 255}, `{"object":"embedding","embedding":[0,255],"index":0,"dtype":"ubinary"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newScriptedServer(t, embeddingsResponse(tt.embedding))
+			res, err := srv.Client().CreateEmbeddings(&EmbeddingsRequestParams{Model: "mistral-embed", Input: []string{"a"}, OutputDtype: tt.dtype})
+			assert.NoError(t, err)
+
+			data, err := json.Marshal(res)
+			assert.NoError(t, err)
+			var restored EmbeddingResponse
+			assert.NoError(t, json.Unmarshal(data, &restored))
+			encoded, err := json.Marshal(restored.Data[0])
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.encoded, string(encoded))
+
+			original, decoded := &res.Data[0], &restored.Data[0]
+			assert.Equal(t, original.Dtype(), decoded.Dtype())
+			assert.Equal(t, original.Float32(), decoded.Float32())
+			assert.Equal(t, original.Int8(), decoded.Int8())
+			assert.Equal(t, original.Uint8(), decoded.Uint8())
+		})
+	}
+}
+
+func TestCreateEmbeddingsRequestData(t *testing.T) {
+	srv := newScriptedServer(t, embeddingsResponse([]int{1}), embeddingsResponse([]int{300}))
+	client := srv.Client()
+
+	_, err := client.CreateEmbeddings(&EmbeddingsRequestParams{
+		Model:           "mistral-embed",
+		Input:           []string{"a"},
+		EncodingFormat:  EmbeddingEncodingFloat,
+		OutputDimension: Ptr(256),
+		OutputDtype:     EmbeddingDtypeInt8,
+	})
+	assert.NoError(t, err)
+	requestData := srv.Requests()[0].JSON()
+	assert.Equal(t, "float", requestData["encoding_format"])
+	assert.Equal(t, float64(256), requestData["output_dimension"])
+	assert.Equal(t, "int8", requestData["output_dtype"])
+
+	_, err = client.CreateEmbeddings(&EmbeddingsRequestParams{Model: "mistral-embed", Input: []string{"a"}, OutputDtype: EmbeddingDtypeUint8})
+	assert.ErrorContains(t, err, "error decoding embeddings: embedding 0")
+}
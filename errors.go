@@ -1,7 +1,23 @@
 package mistral
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrRateLimited matches API errors caused by exceeding a rate limit (HTTP 429).
+	ErrRateLimited = errors.New("mistral: rate limited")
+	// ErrAuthentication matches API errors caused by a missing, invalid or unauthorized API key (HTTP 401/403).
+	ErrAuthentication = errors.New("mistral: authentication failed")
+	// ErrContextLengthExceeded matches API errors caused by a prompt that does not fit in the model's context window.
+	ErrContextLengthExceeded = errors.New("mistral: context length exceeded")
+	// ErrModelNotFound matches API errors caused by an unknown or inaccessible model.
+	ErrModelNotFound = errors.New("mistral: model not found")
 )
 
 // MistralError is the base error type for all Mistral errors.
@@ -18,27 +34,169 @@ type MistralAPIError struct {
 	MistralError
 	HTTPStatus int
 	Headers    map[string][]string
+	Type       string // The error type reported by the API, e.g. "invalid_request_error".
+	Code       string // The error code reported by the API.
+	Param      string // The request parameter the error relates to, if any.
+	RequestID  string // The request id reported in the body or response headers, useful when contacting support.
+	Body       string // The raw response body.
+	Attempts   int    // The number of attempts made before giving up.
 }
 
 func NewMistralAPIError(message string, httpStatus int, headers map[string][]string) *MistralAPIError {
 	return &MistralAPIError{
 		MistralError: MistralError{Message: message},
-		HTTPStatus:       httpStatus,
-		Headers:          headers,
+		HTTPStatus:   httpStatus,
+		Headers:      headers,
 	}
 }
 
 func (e *MistralAPIError) Error() string {
+	if e.HTTPStatus == 0 {
+		// Errors sent in the body of a stream arrive after a successful response status.
+		return e.Message
+	}
+	if e.Attempts > 1 {
+		return fmt.Sprintf("%s (HTTP status: %d after %d attempts)", e.Message, e.HTTPStatus, e.Attempts)
+	}
 	return fmt.Sprintf("%s (HTTP status: %d)", e.Message, e.HTTPStatus)
 }
 
+// Is allows the error to be matched against ErrRateLimited, ErrAuthentication, ErrContextLengthExceeded and
+// ErrModelNotFound with errors.Is.
+func (e *MistralAPIError) Is(target error) bool {
+	switch target {
+	case ErrRateLimited:
+		return e.HTTPStatus == http.StatusTooManyRequests
+	case ErrAuthentication:
+		return e.HTTPStatus == http.StatusUnauthorized || e.HTTPStatus == http.StatusForbidden
+	case ErrContextLengthExceeded:
+		return e.HTTPStatus == http.StatusBadRequest && e.messageContains(
+			"too large for model", "context length", "maximum context", "context window", "too many tokens",
+		)
+	case ErrModelNotFound:
+		if e.Type == "invalid_model" {
+			return true
+		}
+		return (e.HTTPStatus == http.StatusNotFound || e.HTTPStatus == http.StatusBadRequest) && e.messageContains(
+			"invalid model", "model not found", "no such model", "does not exist",
+		)
+	}
+	return false
+}
+
+func (e *MistralAPIError) messageContains(fragments ...string) bool {
+	message := strings.ToLower(e.Message)
+	for _, fragment := range fragments {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
 // MistralConnectionError is returned when the SDK cannot reach the API server for any reason.
 type MistralConnectionError struct {
 	MistralError
+	Err error // The underlying transport error, if any.
 }
 
 func NewMistralConnectionError(message string) *MistralConnectionError {
 	return &MistralConnectionError{
 		MistralError: MistralError{Message: message},
 	}
-}
\ No newline at end of file
+}
+
+func (e *MistralConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// IsRateLimited reports whether err is an API error caused by exceeding a rate limit.
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+// IsAuthError reports whether err is an API error caused by a missing, invalid or unauthorized API key.
+func IsAuthError(err error) bool {
+	return errors.Is(err, ErrAuthentication)
+}
+
+// IsContextLengthExceeded reports whether err is an API error caused by a prompt that exceeds the model's context window.
+func IsContextLengthExceeded(err error) bool {
+	return errors.Is(err, ErrContextLengthExceeded)
+}
+
+// IsModelNotFound reports whether err is an API error caused by an unknown or inaccessible model.
+func IsModelNotFound(err error) bool {
+	return errors.Is(err, ErrModelNotFound)
+}
+
+// newConnectionError wraps a transport failure in a MistralConnectionError.
+func newConnectionError(err error) *MistralConnectionError {
+	connErr := NewMistralConnectionError(err.Error())
+	connErr.Err = err
+	return connErr
+}
+
+// apiErrorBody is the JSON error body returned by the API. Message and detail may be strings or structured values.
+type apiErrorBody struct {
+	Object    string          `json:"object"`
+	Message   json.RawMessage `json:"message"`
+	Detail    json.RawMessage `json:"detail"`
+	Type      string          `json:"type"`
+	Param     json.RawMessage `json:"param"`
+	Code      json.RawMessage `json:"code"`
+	RequestID string          `json:"request_id"`
+}
+
+// newAPIErrorFromResponse builds a MistralAPIError from an error response, parsing the JSON error body when present.
+func newAPIErrorFromResponse(resp *http.Response, body []byte) *MistralAPIError {
+	apiErr := NewMistralAPIError(strings.TrimSpace(string(body)), resp.StatusCode, resp.Header)
+	apiErr.Body = string(body)
+	apiErr.RequestID = resp.Header.Get("X-Request-Id")
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = resp.Header.Get("Mistral-Correlation-Id")
+	}
+
+	var errBody apiErrorBody
+	if err := json.Unmarshal(body, &errBody); err == nil {
+		apiErr.applyBody(errBody)
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+
+	return apiErr
+}
+
+// applyBody copies the fields of a parsed JSON error body onto the error.
+func (e *MistralAPIError) applyBody(body apiErrorBody) {
+	if message := rawJSONString(body.Message); message != "" {
+		e.Message = message
+	} else if detail := rawJSONString(body.Detail); detail != "" {
+		e.Message = detail
+	}
+	e.Type = body.Type
+	e.Code = rawJSONString(body.Code)
+	e.Param = rawJSONString(body.Param)
+	if body.RequestID != "" {
+		e.RequestID = body.RequestID
+	}
+}
+
+// rawJSONString renders a raw JSON value as a string: strings are unquoted, null becomes empty and any other value
+// is returned as compact JSON.
+func rawJSONString(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
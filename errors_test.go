@@ -0,0 +1,86 @@
+package mistral
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIErrorParsing(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-123")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"object":"error","message":"Invalid model: invalid-model","type":"invalid_model","param":null,"code":"1500"}`))
+	})
+
+	client := srv.Client()
+	_, err := client.FIM(&FIMRequestParams{Model: "invalid-model", Prompt: "def f("})
+
+	var apiErr *MistralAPIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.HTTPStatus)
+	assert.Equal(t, "Invalid model: invalid-model", apiErr.Message)
+	assert.Equal(t, "invalid_model", apiErr.Type)
+	assert.Equal(t, "1500", apiErr.Code)
+	assert.Equal(t, "", apiErr.Param)
+	assert.Equal(t, "req-123", apiErr.RequestID)
+	assert.Equal(t, 1, apiErr.Attempts)
+
+	assert.True(t, IsModelNotFound(err))
+	assert.False(t, IsRateLimited(err))
+	assert.False(t, IsAuthError(err))
+	assert.False(t, IsContextLengthExceeded(err))
+}
+
+func TestAPIErrorSentinels(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		target error
+	}{
+		{"rate limited", http.StatusTooManyRequests, `{"message":"Requests rate limit exceeded"}`, ErrRateLimited},
+		{"unauthorized", http.StatusUnauthorized, `{"message":"Unauthorized","request_id":"abc"}`, ErrAuthentication},
+		{"forbidden", http.StatusForbidden, `{"detail":"Forbidden"}`, ErrAuthentication},
+		{"context length", http.StatusBadRequest, `{"object":"error","message":"Prompt contains 40000 tokens, too large for model with 32768 maximum context length","type":"invalid_request_error","code":"3051"}`, ErrContextLengthExceeded},
+		{"not found", http.StatusNotFound, `{"message":"Model not found"}`, ErrModelNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			err := error(newAPIErrorFromResponse(resp, []byte(tt.body)))
+			assert.ErrorIs(t, err, tt.target)
+			assert.NotEmpty(t, err.Error())
+		})
+	}
+}
+
+func TestAPIErrorStructuredMessage(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusUnprocessableEntity, Header: http.Header{}}
+	apiErr := newAPIErrorFromResponse(resp, []byte(`{"object":"error","message":{"detail":[{"loc":["body","model"],"msg":"field required"}]},"type":"invalid_request_error","code":2000}`))
+	assert.Equal(t, `{"detail":[{"loc":["body","model"],"msg":"field required"}]}`, apiErr.Message)
+	assert.Equal(t, "2000", apiErr.Code)
+
+	apiErr = newAPIErrorFromResponse(&http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{}}, nil)
+	assert.Equal(t, "Bad Gateway", apiErr.Message)
+}
+
+func TestConnectionError(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	srv.Close()
+
+	client := srv.Client(WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	_, err := client.ListModels()
+
+	var connErr *MistralConnectionError
+	assert.True(t, errors.As(err, &connErr))
+	assert.NotNil(t, connErr.Err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = client.ListModelsContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -1,7 +1,9 @@
 package mistral
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net/http"
 )
 
@@ -11,7 +13,10 @@ type FIMRequestParams struct {
 	Prompt      string   `json:"prompt"`
 	Suffix      string   `json:"suffix"`
 	MaxTokens   int      `json:"max_tokens"`
+	MinTokens   *int     `json:"min_tokens,omitempty"` // The minimum number of tokens to generate. Nil leaves it unset.
 	Temperature float64  `json:"temperature"`
+	TopP        *float64 `json:"top_p,omitempty"`       // Nucleus sampling probability mass. Nil leaves it unset.
+	RandomSeed  *int     `json:"random_seed,omitempty"` // The seed to use for random sampling. Nil leaves it unset.
 	Stop        []string `json:"stop,omitempty"`
 }
 
@@ -34,19 +39,12 @@ type FIMCompletionResponseChoice struct {
 
 // FIM sends a FIM request and returns the completion response.
 func (c *MistralClient) FIM(params *FIMRequestParams) (*FIMCompletionResponse, error) {
-	requestData := map[string]interface{}{
-		"model":       params.Model,
-		"prompt":      params.Prompt,
-		"suffix":      params.Suffix,
-		"max_tokens":  params.MaxTokens,
-		"temperature": params.Temperature,
-	}
-
-	if params.Stop != nil {
-		requestData["stop"] = params.Stop
-	}
+	return c.FIMContext(context.Background(), params)
+}
 
-	response, err := c.request(http.MethodPost, requestData, "v1/fim/completions", false, nil)
+// FIMContext is like FIM but the request, including any retries, is bound to the given context.
+func (c *MistralClient) FIMContext(ctx context.Context, params *FIMRequestParams) (*FIMCompletionResponse, error) {
+	response, err := c.request(ctx, http.MethodPost, fimRequestData(params, false), "v1/fim/completions", false, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -64,3 +62,64 @@ func (c *MistralClient) FIM(params *FIMRequestParams) (*FIMCompletionResponse, e
 
 	return &fimResponse, nil
 }
+
+// FIMStream sends a FIM request and returns a channel to receive the streamed completion. The streamed responses
+// share the format of chat streams, with the completion text in Choices[].Delta.Content.
+func (c *MistralClient) FIMStream(params *FIMRequestParams) (<-chan ChatCompletionStreamResponse, error) {
+	return c.FIMStreamContext(context.Background(), params)
+}
+
+// FIMStreamContext is like FIMStream but the request and the streaming goroutine are bound to the given context.
+func (c *MistralClient) FIMStreamContext(ctx context.Context, params *FIMRequestParams) (<-chan ChatCompletionStreamResponse, error) {
+	stream, err := c.OpenFIMStream(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return streamToChannel(ctx, stream), nil
+}
+
+// OpenFIMStream sends a FIM request and returns a ChatStreamReader to iterate over the streamed completion.
+// The caller must Close the reader once done with it.
+func (c *MistralClient) OpenFIMStream(ctx context.Context, params *FIMRequestParams) (*ChatStreamReader, error) {
+	response, err := c.request(ctx, http.MethodPost, fimRequestData(params, true), "v1/fim/completions", true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, ok := response.(io.ReadCloser)
+	if !ok {
+		return nil, fmt.Errorf("invalid response type: %T", response)
+	}
+
+	return newChatStreamReader(respBody), nil
+}
+
+// fimRequestData builds the request body for the FIM endpoint, leaving out optional parameters that are unset.
+func fimRequestData(params *FIMRequestParams, stream bool) map[string]interface{} {
+	requestData := map[string]interface{}{
+		"model":       params.Model,
+		"prompt":      params.Prompt,
+		"suffix":      params.Suffix,
+		"max_tokens":  params.MaxTokens,
+		"temperature": params.Temperature,
+	}
+
+	if params.MinTokens != nil {
+		requestData["min_tokens"] = *params.MinTokens
+	}
+	if params.TopP != nil {
+		requestData["top_p"] = *params.TopP
+	}
+	if params.RandomSeed != nil {
+		requestData["random_seed"] = *params.RandomSeed
+	}
+	if params.Stop != nil {
+		requestData["stop"] = params.Stop
+	}
+	if stream {
+		requestData["stream"] = true
+	}
+
+	return requestData
+}
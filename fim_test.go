@@ -7,6 +7,7 @@ import (
 )
 
 func TestFIM(t *testing.T) {
+	skipWithoutAPIKey(t, "MISTRAL_API_KEY")
 	client := NewMistralClientDefault("")
 	params := FIMRequestParams{
 		Model:       ModelCodestralLatest,
@@ -26,6 +27,7 @@ func TestFIM(t *testing.T) {
 }
 
 func TestFIMWithStop(t *testing.T) {
+	skipWithoutAPIKey(t, "MISTRAL_API_KEY")
 	client := NewMistralClientDefault("")
 	params := FIMRequestParams{
 		Model:       ModelCodestralLatest,
@@ -45,6 +47,7 @@ func TestFIMWithStop(t *testing.T) {
 }
 
 func TestFIMInvalidModel(t *testing.T) {
+	skipWithoutAPIKey(t, "MISTRAL_API_KEY")
 	client := NewMistralClientDefault("")
 	params := FIMRequestParams{
 		Model:       "invalid-model",
@@ -57,3 +60,53 @@ func TestFIMInvalidModel(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, res)
 }
+
+func TestFIMStream(t *testing.T) {
+	srv := newScriptedServer(t, testStream{
+		`{"id":"1","model":"codestral-latest","choices":[{"index":0,"delta":{"role":"assistant","content":"a, "}}]}`,
+		`{"id":"1","model":"codestral-latest","choices":[{"index":0,"delta":{"content":"b):"},"finish_reason":"stop"}]}`,
+	})
+
+	client := srv.Client()
+	resChan, err := client.FIMStream(&FIMRequestParams{
+		Model:      ModelCodestralLatest,
+		Prompt:     "def f(",
+		Suffix:     "return a + b",
+		MaxTokens:  64,
+		MinTokens:  Ptr(1),
+		TopP:       Ptr(0.9),
+		RandomSeed: Ptr(7),
+	})
+	assert.NoError(t, err)
+
+	totalOutput := ""
+	var finishReason FinishReason
+	for res := range resChan {
+		assert.NoError(t, res.Error)
+		totalOutput += res.Choices[0].Delta.Content
+		finishReason = res.Choices[0].FinishReason
+	}
+	assert.Equal(t, "a, b):", totalOutput)
+	assert.Equal(t, FinishReasonStop, finishReason)
+
+	request := srv.Requests()[0]
+	assert.Equal(t, "/v1/fim/completions", request.Path)
+	requestData := request.JSON()
+	assert.Equal(t, true, requestData["stream"])
+	assert.Equal(t, 0.9, requestData["top_p"])
+	assert.Equal(t, float64(1), requestData["min_tokens"])
+	assert.Equal(t, float64(7), requestData["random_seed"])
+}
+
+func TestFIMRequestData(t *testing.T) {
+	requestData := fimRequestData(&FIMRequestParams{Model: ModelCodestralLatest, Prompt: "def f("}, false)
+	assert.NotContains(t, requestData, "min_tokens")
+	assert.NotContains(t, requestData, "top_p")
+	assert.NotContains(t, requestData, "random_seed")
+	assert.NotContains(t, requestData, "stream")
+
+	requestData = fimRequestData(&FIMRequestParams{Model: ModelCodestralLatest, TopP: Ptr(0.0), MinTokens: Ptr(0)}, true)
+	assert.Equal(t, 0.0, requestData["top_p"])
+	assert.Equal(t, 0, requestData["min_tokens"])
+	assert.Equal(t, true, requestData["stream"])
+}
@@ -0,0 +1,86 @@
+package mistral
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrToolResultMismatch is returned when the tool calls in a conversation do not match its tool result messages.
+var ErrToolResultMismatch = errors.New("tool calls and tool results do not match")
+
+// SystemMessage returns a system message with the given content.
+func SystemMessage(content string) ChatMessage {
+	return ChatMessage{Role: RoleSystem, Content: content}
+}
+
+// UserMessage returns a user message with the given content.
+func UserMessage(content string) ChatMessage {
+	return ChatMessage{Role: RoleUser, Content: content}
+}
+
+// AssistantMessage returns an assistant message with the given content.
+func AssistantMessage(content string) ChatMessage {
+	return ChatMessage{Role: RoleAssistant, Content: content}
+}
+
+// ToolResultMessage returns the tool message answering call. Strings and raw JSON are sent as they are, errors are
+// sent as their message and any other value is encoded as JSON.
+func ToolResultMessage(call ToolCall, result any) (ChatMessage, error) {
+	var content string
+	switch v := result.(type) {
+	case string:
+		content = v
+	case json.RawMessage:
+		content = string(v)
+	case []byte:
+		content = string(v)
+	case error:
+		content = v.Error()
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ChatMessage{}, fmt.Errorf("error encoding result of tool call %s: %w", call.Id, err)
+		}
+		content = string(data)
+	}
+
+	return ChatMessage{
+		Role:       RoleTool,
+		Content:    content,
+		ToolCallId: call.Id,
+		Name:       call.Function.Name,
+	}, nil
+}
+
+// ValidateToolResults checks that every tool call made by the assistant has a matching tool result message and that
+// every tool result message answers a preceding tool call. Clients created with WithToolResultValidation run it
+// before sending a chat request.
+func ValidateToolResults(messages []ChatMessage) error {
+	pending := map[string]string{}
+	for i, message := range messages {
+		switch message.Role {
+		case RoleAssistant:
+			for _, call := range message.ToolCalls {
+				pending[call.Id] = call.Function.Name
+			}
+		case RoleTool:
+			if message.ToolCallId == "" {
+				return fmt.Errorf("%w: tool message %d has no tool_call_id", ErrToolResultMismatch, i)
+			}
+			if _, ok := pending[message.ToolCallId]; !ok {
+				return fmt.Errorf("%w: tool message %d answers unknown tool call %q", ErrToolResultMismatch, i, message.ToolCallId)
+			}
+			delete(pending, message.ToolCallId)
+		}
+	}
+
+	for _, message := range messages {
+		for _, call := range message.ToolCalls {
+			if _, ok := pending[call.Id]; ok {
+				return fmt.Errorf("%w: tool call %q (%s) has no tool result message", ErrToolResultMismatch, call.Id, call.Function.Name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package mistral
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolResultMessage(t *testing.T) {
+	call := ToolCall{Id: "call1", Type: ToolTypeFunction, Function: FunctionCall{Name: "get_weather", Arguments: `{}`}}
+
+	msg, err := ToolResultMessage(call, map[string]interface{}{"temperature": 82})
+	assert.NoError(t, err)
+	assert.Equal(t, ChatMessage{Role: RoleTool, Content: `{"temperature":82}`, ToolCallId: "call1", Name: "get_weather"}, msg)
+
+	msg, err = ToolResultMessage(call, "sunny")
+	assert.NoError(t, err)
+	assert.Equal(t, "sunny", msg.Content)
+
+	msg, err = ToolResultMessage(call, errors.New("service unavailable"))
+	assert.NoError(t, err)
+	assert.Equal(t, "service unavailable", msg.Content)
+
+	_, err = ToolResultMessage(call, make(chan int))
+	assert.Error(t, err)
+}
+
+func TestValidateToolResults(t *testing.T) {
+	call1 := ToolCall{Id: "call1", Function: FunctionCall{Name: "a"}}
+	call2 := ToolCall{Id: "call2", Function: FunctionCall{Name: "b"}}
+	result1, _ := ToolResultMessage(call1, "1")
+	result2, _ := ToolResultMessage(call2, "2")
+
+	history := []ChatMessage{
+		UserMessage("hi"),
+		{Role: RoleAssistant, ToolCalls: []ToolCall{call1, call2}},
+		result1,
+		result2,
+		AssistantMessage("done"),
+	}
+	assert.NoError(t, ValidateToolResults(history))
+
+	err := ValidateToolResults(history[:3])
+	assert.ErrorIs(t, err, ErrToolResultMismatch)
+	assert.ErrorContains(t, err, "call2")
+
+	err = ValidateToolResults([]ChatMessage{UserMessage("hi"), {Role: RoleTool, Content: "x"}})
+	assert.ErrorIs(t, err, ErrToolResultMismatch)
+
+	err = ValidateToolResults([]ChatMessage{UserMessage("hi"), result1})
+	assert.ErrorIs(t, err, ErrToolResultMismatch)
+
+}
+
+func TestChatToolResultValidation(t *testing.T) {
+	orphan := []ChatMessage{UserMessage("hi"), {Role: RoleTool, Content: "x"}}
+	srv := newScriptedServer(t, answerResponse("ok"))
+
+	// Without the option the messages are sent as they are.
+	res, err := srv.Client().Chat(ModelMistralSmallLatest, orphan, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res.Choices[0].Message.Content)
+
+	_, err = srv.Client(WithToolResultValidation()).Chat(ModelMistralSmallLatest, orphan, nil)
+	assert.ErrorIs(t, err, ErrToolResultMismatch)
+	_, err = srv.Client(WithToolResultValidation()).OpenChatStream(context.Background(), ModelMistralSmallLatest, orphan, nil)
+	assert.ErrorIs(t, err, ErrToolResultMismatch)
+	assert.Len(t, srv.Requests(), 1)
+}
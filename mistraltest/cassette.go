@@ -0,0 +1,335 @@
+package mistraltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gage-technologies/mistral-go"
+)
+
+// RecordEnv is the environment variable that makes ModeFromEnv record cassettes instead of replaying them.
+const RecordEnv = "MISTRAL_RECORD"
+
+// redactedHeaders are replaced by Redacted in recorded cassettes so fixtures never contain credentials.
+var redactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// Redacted replaces the value of sensitive headers in recorded cassettes.
+const Redacted = "REDACTED"
+
+// Mode selects whether a Recorder records or replays traffic.
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette and fails the test on requests it does not contain.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the API and saves the traffic to the cassette when the test ends.
+	ModeRecord
+)
+
+// ModeFromEnv returns ModeRecord if RecordEnv is set and ModeReplay otherwise.
+func ModeFromEnv() Mode {
+	if os.Getenv(RecordEnv) != "" {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// Cassette is the file format of recorded traffic.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a recorded request. JSON bodies are stored as JSON so fixtures are easy to review.
+type RecordedRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Query  string          `json:"query,omitempty"`
+	Header http.Header     `json:"header,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// RecordedResponse is a recorded response. Streamed responses are stored as the raw server-sent events.
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// Recorder is an http.RoundTripper that records client traffic to a cassette file or replays it from one. Plug it
+// into a client with mistral.WithTransport, or use Client:
+//
+//	rec := mistraltest.NewRecorder(t, "testdata/chat.json", mistraltest.ModeFromEnv())
+//	client := rec.Client()
+//
+// When replaying, requests are matched by method, path, query and JSON body, ignoring formatting and key order.
+// Every recorded interaction is replayed at most once, so repeated requests are answered in recording order.
+type Recorder struct {
+	Upstream http.RoundTripper // The transport recorded requests are sent through. Defaults to http.DefaultTransport.
+
+	t    testing.TB
+	path string
+	mode Mode
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewRecorder creates a recorder for the cassette at path. In ModeReplay the cassette is loaded and the test fails
+// if it cannot be read; in ModeRecord the cassette is written when the test ends.
+func NewRecorder(t testing.TB, path string, mode Mode) *Recorder {
+	t.Helper()
+	r := &Recorder{t: t, path: path, mode: mode}
+
+	if mode == ModeReplay {
+		cassette, err := LoadCassette(path)
+		if err != nil {
+			t.Fatalf("mistraltest: %v (set %s=1 to record it)", err, RecordEnv)
+		}
+		r.interactions = cassette.Interactions
+		r.used = make([]bool, len(r.interactions))
+		return r
+	}
+
+	t.Cleanup(func() {
+		if err := r.Save(); err != nil {
+			t.Errorf("mistraltest: %v", err)
+		}
+	})
+	return r
+}
+
+// Client returns a client that sends its requests through the recorder. opts are applied last. When recording, the
+// API key is read from MISTRAL_API_KEY as usual.
+func (r *Recorder) Client(opts ...mistral.ClientOption) *mistral.MistralClient {
+	apiKey := ""
+	if r.mode == ModeReplay {
+		apiKey = "replayed-api-key"
+	}
+	opts = append([]mistral.ClientOption{mistral.WithTransport(r)}, opts...)
+	return mistral.NewMistralClientWithOptions(apiKey, opts...)
+}
+
+// Interactions returns the interactions recorded or loaded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// RoundTrip records or replays a request. The request is not modified; in ModeRecord a clone is sent upstream.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, body)
+	}
+	return r.record(req, body)
+}
+
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	key := matchKey(req.Method, req.URL.Path, req.URL.RawQuery, recordedBody(body))
+
+	r.mu.Lock()
+	var match *Interaction
+	for i := range r.interactions {
+		recorded := r.interactions[i].Request
+		if !r.used[i] && matchKey(recorded.Method, recorded.Path, recorded.Query, recorded.Body) == key {
+			r.used[i] = true
+			match = &r.interactions[i]
+			break
+		}
+	}
+	r.mu.Unlock()
+
+	if match == nil {
+		err := fmt.Errorf("mistraltest: no recorded interaction in %s matches %s %s with body %s", r.path, req.Method, req.URL.Path, body)
+		r.t.Errorf("%v", err)
+		return nil, err
+	}
+
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", match.Response.Status, http.StatusText(match.Response.Status)),
+		StatusCode:    match.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        match.Response.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(match.Response.Body)),
+		ContentLength: int64(len(match.Response.Body)),
+		Request:       req,
+	}
+	if resp.Header == nil {
+		resp.Header = http.Header{}
+	}
+	return resp, nil
+}
+
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	upstream := r.Upstream
+	if upstream == nil {
+		upstream = http.DefaultTransport
+	}
+	// The body of req has been consumed, so the upstream request gets its own copy of it.
+	out := req.Clone(req.Context())
+	if req.Body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+		out.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		out.ContentLength = int64(len(body))
+	}
+	resp, err := upstream.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	resp.Request = req
+
+	recorded := RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.RawQuery,
+		Header: redact(req.Header),
+		Body:   recordedBody(body),
+	}
+	// The response is recorded once the client has read it, so streams are passed through as they arrive.
+	resp.Body = &recordingBody{ReadCloser: resp.Body, done: func(data []byte) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.interactions = append(r.interactions, Interaction{
+			Request:  recorded,
+			Response: RecordedResponse{Status: resp.StatusCode, Header: redact(resp.Header), Body: string(data)},
+		})
+	}}
+	return resp, nil
+}
+
+// Save writes the recorded interactions to the cassette file. It is called automatically at the end of the test in
+// ModeRecord.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	cassette := Cassette{Interactions: append([]Interaction{}, r.interactions...)}
+	r.mu.Unlock()
+
+	data, err := json.MarshalIndent(cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("error saving cassette: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("error saving cassette: %w", err)
+	}
+	return nil
+}
+
+// LoadCassette reads a cassette file.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error loading cassette: %w", err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("error decoding cassette %s: %w", path, err)
+	}
+	return &cassette, nil
+}
+
+// recordingBody passes a response body through and hands the data read to done once the body is exhausted or
+// closed.
+type recordingBody struct {
+	io.ReadCloser
+	buf      bytes.Buffer
+	done     func(data []byte)
+	finished bool
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *recordingBody) finish() {
+	if !b.finished {
+		b.finished = true
+		b.done(b.buf.Bytes())
+	}
+}
+
+// recordedBody stores a JSON body as is and any other body as a JSON string.
+func recordedBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, body); err == nil {
+		return buf.Bytes()
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
+
+// matchKey identifies a request for replay. JSON bodies are normalized so formatting and key order do not matter.
+func matchKey(method, path, query string, body json.RawMessage) string {
+	return method + " " + path + "?" + query + "\n" + string(normalizeJSON(body))
+}
+
+func normalizeJSON(body []byte) []byte {
+	var v any
+	if len(body) == 0 || json.Unmarshal(body, &v) != nil {
+		return body
+	}
+	// Maps are marshaled with sorted keys.
+	normalized, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return normalized
+}
+
+func redact(header http.Header) http.Header {
+	header = header.Clone()
+	for _, key := range redactedHeaders {
+		if header.Get(key) != "" {
+			header.Set(key, Redacted)
+		}
+	}
+	return header
+}
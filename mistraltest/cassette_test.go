@@ -0,0 +1,117 @@
+package mistraltest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gage-technologies/mistral-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorderRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "chat.json")
+	messages := []mistral.ChatMessage{mistral.UserMessage("Hi")}
+
+	// Record against a fake server with a secret key.
+	srv := NewServer(t)
+	srv.Enqueue(PathChat, ChatResponse("Hello!"), ChatStreamResponse("Hel", "lo!"))
+	rt := &recordingT{TB: t}
+	rec := NewRecorder(rt, path, ModeRecord)
+	t.Setenv("MISTRAL_API_KEY", "secret-key")
+	client := rec.Client(mistral.WithBaseURL(srv.URL))
+
+	res, err := client.Chat(mistral.ModelMistralSmallLatest, messages, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello!", res.Choices[0].Message.Content)
+	stream, err := client.OpenChatStream(context.Background(), mistral.ModelMistralSmallLatest, messages, nil)
+	assert.NoError(t, err)
+	streamed, err := stream.Accumulate()
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello!", streamed.Choices[0].Message.Content)
+
+	assert.NoError(t, rec.Save())
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-key")
+	assert.Contains(t, string(data), Redacted)
+	assert.Contains(t, string(data), "data: [DONE]")
+	assert.Empty(t, rt.errors)
+
+	// Replay without a server.
+	srv.Close()
+	rt = &recordingT{TB: t}
+	replay := NewRecorder(rt, path, ModeReplay)
+	assert.Len(t, replay.Interactions(), 2)
+	client = replay.Client(mistral.WithBaseURL("http://mistral.invalid"))
+
+	res, err = client.Chat(mistral.ModelMistralSmallLatest, messages, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello!", res.Choices[0].Message.Content)
+	stream, err = client.OpenChatStream(context.Background(), mistral.ModelMistralSmallLatest, messages, nil)
+	assert.NoError(t, err)
+	streamed, err = stream.Accumulate()
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello!", streamed.Choices[0].Message.Content)
+	assert.Empty(t, rt.errors)
+
+	// Every interaction is replayed once and unmatched requests fail the test.
+	_, err = client.Chat(mistral.ModelMistralSmallLatest, messages, nil)
+	assert.ErrorContains(t, err, "no recorded interaction")
+	assert.Len(t, rt.errors, 1)
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRecorderDoesNotModifyRequest(t *testing.T) {
+	rec := NewRecorder(t, filepath.Join(t.TempDir(), "cassette.json"), ModeRecord)
+	var upstreamBody []byte
+	rec.Upstream = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		upstreamBody, _ = io.ReadAll(req.Body)
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{}`))}, nil
+	})
+
+	body := io.NopCloser(strings.NewReader(`{"model":"m"}`))
+	req, err := http.NewRequest(http.MethodPost, "http://mistral.invalid/v1/chat/completions", body)
+	assert.NoError(t, err)
+	resp, err := rec.RoundTrip(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, `{"model":"m"}`, string(upstreamBody))
+	assert.Equal(t, body, req.Body)
+	assert.Same(t, req, resp.Request)
+}
+
+func TestRecorderMatchesNormalizedJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	cassette := `{"interactions": [{
+		"request": {"method": "POST", "path": "/v1/embeddings", "body": {"model": "mistral-embed", "input": ["a"]}},
+		"response": {"status": 200, "header": {"Content-Type": ["application/json"]}, "body": "{\"data\": []}"}
+	}]}`
+	assert.NoError(t, os.WriteFile(path, []byte(cassette), 0o644))
+
+	rt := &recordingT{TB: t}
+	rec := NewRecorder(rt, path, ModeReplay)
+
+	req, err := http.NewRequest(http.MethodPost, "https://api.mistral.ai/v1/embeddings", bytes.NewBufferString(`{"input":["a"],  "model":"mistral-embed"}`))
+	assert.NoError(t, err)
+	resp, err := rec.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodPost, "https://api.mistral.ai/v1/embeddings", bytes.NewBufferString(`{"input":["b"],"model":"mistral-embed"}`))
+	assert.NoError(t, err)
+	_, err = rec.RoundTrip(req)
+	assert.Error(t, err)
+	assert.Len(t, rt.errors, 1)
+}
@@ -0,0 +1,188 @@
+package mistraltest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gage-technologies/mistral-go"
+)
+
+// Response is a scripted reply of a Server.
+type Response struct {
+	Status int         // The HTTP status. Defaults to 200.
+	Header http.Header // Headers added to the response.
+	Body   any         // The JSON encoded body. Strings and byte slices are sent verbatim.
+
+	// Events, when not nil, streams the response as server-sent events instead of sending Body. Every event is JSON
+	// encoded like Body and the stream ends with [DONE] unless Truncate is set.
+	Events []any
+	// Truncate drops the connection in the middle of an event after sending Events.
+	Truncate bool
+
+	// Check, when set, is called with the request the response answers, so tests can assert on it. It runs on the
+	// goroutine of the HTTP handler rather than the test: t.FailNow, t.Fatal and require assertions mark the test as
+	// failed and abort the response instead of stopping the test, and t.Skip is reported as an error.
+	Check func(t testing.TB, r Request)
+}
+
+// WithCheck returns a copy of the response that calls check with the request it answers.
+func (resp Response) WithCheck(check func(t testing.TB, r Request)) Response {
+	resp.Check = check
+	return resp
+}
+
+var defaultUsage = mistral.UsageInfo{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}
+
+// ChatResponse is a chat completion answering with content.
+func ChatResponse(content string) Response {
+	return ChatMessageResponse(mistral.AssistantMessage(content), mistral.FinishReasonStop)
+}
+
+// ToolCallResponse is a chat completion calling tools.
+func ToolCallResponse(calls ...mistral.ToolCall) Response {
+	return ChatMessageResponse(mistral.ChatMessage{Role: mistral.RoleAssistant, ToolCalls: calls}, mistral.FinishReasonToolCalls)
+}
+
+// ChatMessageResponse is a chat completion with a single choice.
+func ChatMessageResponse(msg mistral.ChatMessage, finishReason mistral.FinishReason) Response {
+	return Response{Body: mistral.ChatCompletionResponse{
+		ID:      "chat-test",
+		Object:  "chat.completion",
+		Created: int(time.Now().Unix()),
+		Model:   mistral.ModelMistralSmallLatest,
+		Choices: []mistral.ChatCompletionResponseChoice{{Message: msg, FinishReason: finishReason}},
+		Usage:   defaultUsage,
+	}}
+}
+
+// ChatStreamResponse streams a chat completion whose content is the concatenation of chunks, one event per chunk.
+func ChatStreamResponse(chunks ...string) Response {
+	events := make([]any, len(chunks))
+	for i, chunk := range chunks {
+		delta := mistral.DeltaMessage{Content: chunk}
+		if i == 0 {
+			delta.Role = mistral.RoleAssistant
+		}
+		events[i] = streamChunk(delta, "")
+	}
+	if len(events) == 0 {
+		events = append(events, streamChunk(mistral.DeltaMessage{Role: mistral.RoleAssistant}, ""))
+	}
+	last := events[len(events)-1].(mistral.ChatCompletionStreamResponse)
+	last.Choices[0].FinishReason = mistral.FinishReasonStop
+	last.Usage = defaultUsage
+	events[len(events)-1] = last
+	return Response{Events: events}
+}
+
+// ToolCallStreamResponse streams a chat completion calling tools. Every call is sent in its own event with its
+// arguments split in two, like the API does for long arguments.
+func ToolCallStreamResponse(calls ...mistral.ToolCall) Response {
+	events := []any{streamChunk(mistral.DeltaMessage{Role: mistral.RoleAssistant}, "")}
+	for i, call := range calls {
+		call.Index = i
+		if call.Type == "" {
+			call.Type = mistral.ToolTypeFunction
+		}
+		args := call.Function.Arguments
+		half := len(args) / 2
+
+		call.Function.Arguments = args[:half]
+		events = append(events, streamChunk(mistral.DeltaMessage{ToolCalls: []mistral.ToolCall{call}}, ""))
+		rest := mistral.ToolCall{Index: i, Function: mistral.FunctionCall{Arguments: args[half:]}}
+		events = append(events, streamChunk(mistral.DeltaMessage{ToolCalls: []mistral.ToolCall{rest}}, ""))
+	}
+
+	last := streamChunk(mistral.DeltaMessage{}, mistral.FinishReasonToolCalls)
+	last.Usage = defaultUsage
+	return Response{Events: append(events, last)}
+}
+
+// TruncatedStream streams chunks like ChatStreamResponse but drops the connection before the stream finishes.
+func TruncatedStream(chunks ...string) Response {
+	events := make([]any, len(chunks))
+	for i, chunk := range chunks {
+		events[i] = streamChunk(mistral.DeltaMessage{Content: chunk}, "")
+	}
+	return Response{Events: events, Truncate: true}
+}
+
+func streamChunk(delta mistral.DeltaMessage, finishReason mistral.FinishReason) mistral.ChatCompletionStreamResponse {
+	return mistral.ChatCompletionStreamResponse{
+		ID:      "chat-test",
+		Object:  "chat.completion.chunk",
+		Model:   mistral.ModelMistralSmallLatest,
+		Choices: []mistral.ChatCompletionResponseChoiceStream{{Delta: delta, FinishReason: finishReason}},
+	}
+}
+
+// FIMResponse is a fill-in-the-middle completion answering with content.
+func FIMResponse(content string) Response {
+	return Response{Body: mistral.FIMCompletionResponse{
+		ID:      "fim-test",
+		Object:  "chat.completion",
+		Created: int(time.Now().Unix()),
+		Model:   mistral.ModelCodestralLatest,
+		Choices: []mistral.FIMCompletionResponseChoice{{Message: mistral.AssistantMessage(content), FinishReason: mistral.FinishReasonStop}},
+		Usage:   defaultUsage,
+	}}
+}
+
+// EmbeddingsResponse returns the given embeddings, one per input.
+func EmbeddingsResponse(vectors ...[]float64) Response {
+	data := make([]mistral.EmbeddingObject, len(vectors))
+	for i, vector := range vectors {
+		data[i] = mistral.EmbeddingObject{Object: "embedding", Embedding: vector, Index: i}
+	}
+	return Response{Body: mistral.EmbeddingResponse{
+		ID:     "embd-test",
+		Object: "list",
+		Data:   data,
+		Model:  "mistral-embed",
+		Usage:  mistral.UsageInfo{PromptTokens: 5 * len(vectors), TotalTokens: 5 * len(vectors)},
+	}}
+}
+
+// ModelsResponse lists models with the given ids.
+func ModelsResponse(ids ...string) Response {
+	models := mistral.ModelList{Object: "list", Data: make([]mistral.ModelCard, len(ids))}
+	for i, id := range ids {
+		models.Data[i] = mistral.ModelCard{ID: id, Object: "model", OwnedBy: "mistralai", Permission: []mistral.ModelPermission{}}
+	}
+	return Response{Body: models}
+}
+
+// ErrorResponse is an API error with the given status and message.
+func ErrorResponse(status int, message string) Response {
+	return Response{
+		Status: status,
+		Body: map[string]any{
+			"object":  "error",
+			"message": message,
+			"type":    strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_"),
+			"code":    strconv.Itoa(status),
+		},
+	}
+}
+
+// RateLimited is a 429 error asking the client to retry after the given delay.
+func RateLimited(retryAfter time.Duration) Response {
+	resp := ErrorResponse(http.StatusTooManyRequests, "Requests rate limit exceeded")
+	resp.Header = http.Header{}
+	resp.Header.Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	resp.Header.Set("Retry-After-Ms", strconv.FormatInt(retryAfter.Milliseconds(), 10))
+	return resp
+}
+
+// ServerError is a 500 error.
+func ServerError() Response {
+	return ErrorResponse(http.StatusInternalServerError, "Internal server error")
+}
+
+// MalformedJSON is a successful response whose body is not valid JSON.
+func MalformedJSON() Response {
+	return Response{Body: `{"id": "chat-test", "choices": [`}
+}
@@ -0,0 +1,392 @@
+// Package mistraltest provides a fake Mistral API server for testing code that uses the mistral package without
+// network access or an API key.
+//
+// The server implements v1/chat/completions (including streaming and tool calls), v1/fim/completions, v1/embeddings
+// and v1/models. Responses are scripted per endpoint and served in order, and every request is recorded so tests
+// can assert on what was sent:
+//
+//	srv := mistraltest.NewServer(t)
+//	srv.Enqueue(mistraltest.PathChat, mistraltest.RateLimited(time.Second), mistraltest.ChatResponse("Hello!"))
+//	client := srv.Client()
+//
+//	res, err := client.Chat(mistral.ModelMistralSmallLatest, messages, nil)
+//	assert.Len(t, srv.Requests(mistraltest.PathChat), 2)
+package mistraltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gage-technologies/mistral-go"
+)
+
+// The paths of the endpoints implemented by Server.
+const (
+	PathChat       = "/v1/chat/completions"
+	PathFIM        = "/v1/fim/completions"
+	PathEmbeddings = "/v1/embeddings"
+	PathModels     = "/v1/models"
+)
+
+// DefaultEmbeddingDimensions is the size of the embeddings generated by the v1/embeddings endpoint when no response
+// is scripted. It matches mistral-embed.
+const DefaultEmbeddingDimensions = 1024
+
+// Request is a request received by a Server.
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// Decode decodes the JSON body of the request into v.
+func (r Request) Decode(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// JSON returns the JSON body of the request as a map, or nil if the body is not a JSON object.
+func (r Request) JSON() map[string]any {
+	var body map[string]any
+	if err := r.Decode(&body); err != nil {
+		return nil
+	}
+	return body
+}
+
+// Model returns the model the request was sent to.
+func (r Request) Model() string {
+	var body struct {
+		Model string `json:"model"`
+	}
+	r.Decode(&body)
+	return body.Model
+}
+
+// Messages returns the messages of a chat completion request.
+func (r Request) Messages() []mistral.ChatMessage {
+	var body struct {
+		Messages []mistral.ChatMessage `json:"messages"`
+	}
+	r.Decode(&body)
+	return body.Messages
+}
+
+// Stream reports whether the request asked for a streamed response.
+func (r Request) Stream() bool {
+	var body struct {
+		Stream bool `json:"stream"`
+	}
+	r.Decode(&body)
+	return body.Stream
+}
+
+// Server is a fake Mistral API server. Scripted responses are served in the order they were enqueued for each
+// endpoint. When an endpoint has no scripted response left, v1/models lists the models of the mistral package,
+// v1/embeddings returns deterministic float embeddings of the inputs, and the completion endpoints fail the test.
+//
+// A Server is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	t        testing.TB
+	mu       sync.Mutex
+	scripts  map[string][]Response
+	handlers map[string]func(Request) Response
+	requests []Request
+}
+
+// NewServer starts a server that is closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	s := &Server{
+		t:        t,
+		scripts:  map[string][]Response{},
+		handlers: map[string]func(Request) Response{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Client returns a client for the server. Its retry policy retries like the default one, but without jitter and
+// with delays of at most a few milliseconds, so retries do not slow tests down. opts are applied last.
+func (s *Server) Client(opts ...mistral.ClientOption) *mistral.MistralClient {
+	policy := mistral.DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 5 * time.Millisecond
+	policy.Jitter = 0
+
+	opts = append([]mistral.ClientOption{mistral.WithBaseURL(s.URL), mistral.WithRetryPolicy(policy)}, opts...)
+	return mistral.NewMistralClientWithOptions("test-api-key", opts...)
+}
+
+// Enqueue scripts the next responses of the endpoint at path.
+func (s *Server) Enqueue(path string, responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[path] = append(s.scripts[path], responses...)
+}
+
+// Handle sets the function that answers requests to path once its scripted responses are used up, replacing the
+// default behavior of the endpoint.
+func (s *Server) Handle(path string, handler func(Request) Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[path] = handler
+}
+
+// Pending returns the number of scripted responses of path that have not been served yet.
+func (s *Server) Pending(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.scripts[path])
+}
+
+// Requests returns the requests received for path, or every request received if path is empty, in order.
+func (s *Server) Requests(path string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var requests []Request
+	for _, r := range s.requests {
+		if path == "" || r.Path == path {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// LastRequest returns the last request received for path, or every request if path is empty. It fails the test if
+// there is none.
+func (s *Server) LastRequest(path string) Request {
+	s.t.Helper()
+	requests := s.Requests(path)
+	if len(requests) == 0 {
+		s.t.Fatalf("mistraltest: no request received for %q", path)
+	}
+	return requests[len(requests)-1]
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req := Request{Method: r.Method, Path: r.URL.Path, Header: r.Header.Clone(), Body: body}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	var resp Response
+	scripted := len(s.scripts[req.Path]) > 0
+	if scripted {
+		resp = s.scripts[req.Path][0]
+		s.scripts[req.Path] = s.scripts[req.Path][1:]
+	}
+	handler := s.handlers[req.Path]
+	s.mu.Unlock()
+
+	if !scripted {
+		if handler == nil {
+			handler = s.defaultHandler(req)
+		}
+		if handler == nil {
+			s.t.Errorf("mistraltest: unexpected request %s %s", req.Method, req.Path)
+			resp = ErrorResponse(http.StatusNotImplemented, fmt.Sprintf("no scripted response for %s %s", req.Method, req.Path))
+		} else {
+			resp = handler(req)
+		}
+	}
+
+	if resp.Check != nil {
+		resp.Check(checkT{s.t}, req)
+	}
+	resp.write(w)
+}
+
+// checkT is the testing.TB passed to Response.Check. Check runs on the goroutine of the HTTP handler, where
+// t.FailNow must not be called, so the functions stopping the test fail it and abort the response instead.
+type checkT struct {
+	testing.TB
+}
+
+func (t checkT) FailNow() {
+	t.TB.Fail()
+	panic(http.ErrAbortHandler)
+}
+
+func (t checkT) Fatal(args ...any) {
+	t.TB.Error(args...)
+	t.FailNow()
+}
+
+func (t checkT) Fatalf(format string, args ...any) {
+	t.TB.Errorf(format, args...)
+	t.FailNow()
+}
+
+func (t checkT) SkipNow() {
+	t.TB.Errorf("mistraltest: Response.Check cannot skip the test")
+	t.FailNow()
+}
+
+func (t checkT) Skip(args ...any) {
+	t.SkipNow()
+}
+
+func (t checkT) Skipf(format string, args ...any) {
+	t.SkipNow()
+}
+
+// defaultHandler returns the handler used for path when nothing is scripted, or nil if the request is unexpected.
+func (s *Server) defaultHandler(r Request) func(Request) Response {
+	switch {
+	case r.Path == PathModels && r.Method == http.MethodGet:
+		return defaultModels
+	case r.Path == PathEmbeddings && r.Method == http.MethodPost:
+		return defaultEmbeddings
+	}
+	return nil
+}
+
+func defaultModels(Request) Response {
+	return ModelsResponse(
+		mistral.ModelMistralLargeLatest,
+		mistral.ModelMistralMediumLatest,
+		mistral.ModelMistralSmallLatest,
+		mistral.ModelCodestralLatest,
+		mistral.ModelOpenMixtral8x7b,
+		mistral.ModelOpenMixtral8x22b,
+		mistral.ModelOpenMistral7b,
+		"mistral-embed",
+	)
+}
+
+func defaultEmbeddings(r Request) Response {
+	var body struct {
+		Input           []string `json:"input"`
+		OutputDimension int      `json:"output_dimension"`
+	}
+	if err := r.Decode(&body); err != nil {
+		return ErrorResponse(http.StatusBadRequest, "invalid embeddings request: "+err.Error())
+	}
+	dimensions := DefaultEmbeddingDimensions
+	if body.OutputDimension > 0 {
+		dimensions = body.OutputDimension
+	}
+
+	vectors := make([][]float64, len(body.Input))
+	for i, input := range body.Input {
+		vectors[i] = Embedding(input, dimensions)
+	}
+	return EmbeddingsResponse(vectors...)
+}
+
+// Embedding returns a deterministic unit vector of the given dimensions for input. Equal inputs have equal
+// embeddings; different inputs are nearly orthogonal.
+func Embedding(input string, dimensions int) []float64 {
+	h := fnv.New64a()
+	h.Write([]byte(input))
+	state := h.Sum64()
+
+	vector := make([]float64, dimensions)
+	var norm float64
+	for i := range vector {
+		// xorshift64* keeps the values well spread for similar inputs.
+		state ^= state >> 12
+		state ^= state << 25
+		state ^= state >> 27
+		v := float64(state*2685821657736338717>>11)/float64(1<<53)*2 - 1
+		vector[i] = v
+		norm += v * v
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] /= norm
+	}
+	return vector
+}
+
+// write sends the response. It must be the last thing the handler does since truncated streams abort the handler.
+func (resp Response) write(w http.ResponseWriter) {
+	for key, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(key, v)
+		}
+	}
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if resp.Events == nil {
+		body, err := resp.body()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(status)
+		w.Write(body)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(status)
+	flusher, _ := w.(http.Flusher)
+	for _, event := range resp.Events {
+		data, err := encode(event)
+		if err != nil {
+			panic(fmt.Sprintf("mistraltest: cannot encode stream event: %v", err))
+		}
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	if resp.Truncate {
+		// Send half an event, then drop the connection like a server that goes away mid-stream.
+		io.WriteString(w, `data: {"id":`)
+		if flusher != nil {
+			flusher.Flush()
+		}
+		panic(http.ErrAbortHandler)
+	}
+	io.WriteString(w, "data: [DONE]\n\n")
+}
+
+func (resp Response) body() ([]byte, error) {
+	if resp.Body == nil {
+		return nil, nil
+	}
+	return encode(resp.Body)
+}
+
+// encode marshals v as JSON. Strings and byte slices are sent verbatim.
+func encode(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -0,0 +1,216 @@
+package mistraltest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gage-technologies/mistral-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingT records the errors reported by a Server instead of failing the test.
+type recordingT struct {
+	testing.TB
+	mu     sync.Mutex
+	errors []string
+	failed bool
+}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errors = append(t.errors, format)
+}
+
+func (t *recordingT) Fail() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failed = true
+}
+
+func TestServerChat(t *testing.T) {
+	srv := NewServer(t)
+	srv.Enqueue(PathChat, ChatResponse("Hello!").WithCheck(func(t testing.TB, r Request) {
+		assert.Equal(t, "Bearer test-api-key", r.Header.Get("Authorization"))
+		assert.Equal(t, mistral.ModelMistralSmallLatest, r.Model())
+	}))
+
+	res, err := srv.Client().Chat(mistral.ModelMistralSmallLatest, []mistral.ChatMessage{mistral.UserMessage("Hi")}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello!", res.Choices[0].Message.Content)
+	assert.Equal(t, 15, res.Usage.TotalTokens)
+
+	r := srv.LastRequest(PathChat)
+	assert.Equal(t, http.MethodPost, r.Method)
+	assert.Equal(t, "Hi", r.Messages()[0].Content)
+	assert.False(t, r.Stream())
+	assert.Equal(t, 0, srv.Pending(PathChat))
+}
+
+func TestServerChatStream(t *testing.T) {
+	srv := NewServer(t)
+	srv.Enqueue(PathChat, ChatStreamResponse("Hel", "lo", "!"))
+
+	stream, err := srv.Client().OpenChatStream(context.Background(), mistral.ModelMistralSmallLatest, []mistral.ChatMessage{mistral.UserMessage("Hi")}, nil)
+	assert.NoError(t, err)
+	res, err := stream.Accumulate()
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello!", res.Choices[0].Message.Content)
+	assert.Equal(t, mistral.FinishReasonStop, res.Choices[0].FinishReason)
+	assert.True(t, srv.LastRequest(PathChat).Stream())
+}
+
+func TestServerToolCallStream(t *testing.T) {
+	srv := NewServer(t)
+	srv.Enqueue(PathChat, ToolCallStreamResponse(
+		mistral.ToolCall{Id: "a", Function: mistral.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+		mistral.ToolCall{Id: "b", Function: mistral.FunctionCall{Name: "get_time", Arguments: `{"zone":"CET"}`}},
+	))
+
+	stream, err := srv.Client().OpenChatStream(context.Background(), mistral.ModelMistralSmallLatest, []mistral.ChatMessage{mistral.UserMessage("Hi")}, nil)
+	assert.NoError(t, err)
+	var completed []mistral.ToolCall
+	for stream.Next() {
+		completed = append(completed, stream.CompletedToolCalls()...)
+	}
+	assert.NoError(t, stream.Err())
+	if assert.Len(t, completed, 2) {
+		assert.Equal(t, "get_weather", completed[0].Function.Name)
+		assert.Equal(t, `{"city":"Paris"}`, completed[0].Function.Arguments)
+		assert.Equal(t, "b", completed[1].Id)
+		assert.Equal(t, `{"zone":"CET"}`, completed[1].Function.Arguments)
+	}
+
+	srv.Enqueue(PathChat, ToolCallResponse(mistral.ToolCall{Id: "c", Type: mistral.ToolTypeFunction, Function: mistral.FunctionCall{Name: "get_weather", Arguments: `{}`}}))
+	res, err := srv.Client().Chat(mistral.ModelMistralSmallLatest, []mistral.ChatMessage{mistral.UserMessage("Hi")}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, mistral.FinishReasonToolCalls, res.Choices[0].FinishReason)
+	assert.Equal(t, "c", res.Choices[0].Message.ToolCalls[0].Id)
+}
+
+func TestServerFIM(t *testing.T) {
+	srv := NewServer(t)
+	srv.Enqueue(PathFIM, FIMResponse("a, b):"))
+
+	res, err := srv.Client().FIM(&mistral.FIMRequestParams{Model: mistral.ModelCodestralLatest, Prompt: "def f(", Suffix: "return a + b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "a, b):", res.Choices[0].Message.Content)
+	assert.Equal(t, "def f(", srv.LastRequest(PathFIM).JSON()["prompt"])
+}
+
+func TestServerEmbeddings(t *testing.T) {
+	srv := NewServer(t)
+	client := srv.Client()
+
+	res, err := client.Embeddings("mistral-embed", []string{"a", "b", "a"})
+	assert.NoError(t, err)
+	if assert.Len(t, res.Data, 3) {
+		assert.Len(t, res.Data[0].Embedding, DefaultEmbeddingDimensions)
+		assert.Equal(t, res.Data[0].Embedding, res.Data[2].Embedding)
+		assert.NotEqual(t, res.Data[0].Embedding, res.Data[1].Embedding)
+		assert.Equal(t, 2, res.Data[2].Index)
+	}
+
+	var norm float64
+	for _, v := range Embedding("a", 16) {
+		norm += v * v
+	}
+	assert.InDelta(t, 1, math.Sqrt(norm), 1e-9)
+
+	res, err = client.CreateEmbeddings(&mistral.EmbeddingsRequestParams{Model: "mistral-embed", Input: []string{"a"}, OutputDimension: mistral.Ptr(16)})
+	assert.NoError(t, err)
+	assert.Equal(t, 16, res.Data[0].Dimension())
+
+	srv.Enqueue(PathEmbeddings, EmbeddingsResponse([]float64{1, 0}))
+	res, err = client.Embeddings("mistral-embed", []string{"a"})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 0}, res.Data[0].Embedding)
+}
+
+func TestServerModels(t *testing.T) {
+	srv := NewServer(t)
+
+	models, err := srv.Client().ListModels()
+	assert.NoError(t, err)
+	assert.Equal(t, mistral.ModelMistralLargeLatest, models.Data[0].ID)
+
+	srv.Handle(PathModels, func(Request) Response { return ModelsResponse("custom") })
+	models, err = srv.Client().ListModels()
+	assert.NoError(t, err)
+	assert.Len(t, models.Data, 1)
+	assert.Equal(t, "custom", models.Data[0].ID)
+}
+
+func TestServerErrors(t *testing.T) {
+	srv := NewServer(t)
+	messages := []mistral.ChatMessage{mistral.UserMessage("Hi")}
+
+	// Rate limits and server errors are retried.
+	var delays []time.Duration
+	policy := mistral.DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.Jitter = 0
+	policy.OnRetry = func(event mistral.RetryEvent) { delays = append(delays, event.Delay) }
+	srv.Enqueue(PathChat, RateLimited(20*time.Millisecond), ServerError(), ChatResponse("ok"))
+	res, err := srv.Client(mistral.WithRetryPolicy(policy)).Chat(mistral.ModelMistralSmallLatest, messages, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res.Choices[0].Message.Content)
+	assert.Len(t, srv.Requests(PathChat), 3)
+	if assert.Len(t, delays, 2) {
+		assert.Equal(t, 20*time.Millisecond, delays[0])
+	}
+
+	srv.Enqueue(PathChat, RateLimited(time.Second))
+	_, err = srv.Client(mistral.WithRetryPolicy(mistral.RetryPolicy{MaxAttempts: 1})).Chat(mistral.ModelMistralSmallLatest, messages, nil)
+	assert.True(t, mistral.IsRateLimited(err))
+	var apiErr *mistral.MistralAPIError
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, "1", http.Header(apiErr.Headers).Get("Retry-After"))
+		assert.Equal(t, "Requests rate limit exceeded", apiErr.Message)
+	}
+
+	srv.Enqueue(PathChat, MalformedJSON())
+	_, err = srv.Client().Chat(mistral.ModelMistralSmallLatest, messages, nil)
+	assert.Error(t, err)
+
+	srv.Enqueue(PathChat, TruncatedStream("Hel", "lo"))
+	stream, err := srv.Client().OpenChatStream(context.Background(), mistral.ModelMistralSmallLatest, messages, nil)
+	assert.NoError(t, err)
+	_, err = stream.Accumulate()
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF), "%v", err)
+}
+
+func TestServerUnexpectedRequest(t *testing.T) {
+	rt := &recordingT{TB: t}
+	srv := NewServer(rt)
+
+	_, err := srv.Client().Chat(mistral.ModelMistralSmallLatest, []mistral.ChatMessage{mistral.UserMessage("Hi")}, nil)
+	assert.ErrorContains(t, err, "no scripted response for POST /v1/chat/completions")
+	assert.Len(t, rt.errors, 1)
+	assert.Len(t, srv.Requests(""), 1)
+}
+
+func TestServerCheckFailNow(t *testing.T) {
+	rt := &recordingT{TB: t}
+	srv := NewServer(rt)
+	srv.Enqueue(PathChat, ChatResponse("Hello!").WithCheck(func(t testing.TB, r Request) {
+		require.Equal(t, "another-model", r.Model())
+		t.Errorf("not reached")
+	}))
+
+	client := srv.Client(mistral.WithRetryPolicy(mistral.RetryPolicy{MaxAttempts: 1}))
+	_, err := client.Chat(mistral.ModelMistralSmallLatest, []mistral.ChatMessage{mistral.UserMessage("Hi")}, nil)
+	assert.Error(t, err)
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	assert.True(t, rt.failed)
+	assert.Len(t, rt.errors, 1)
+}
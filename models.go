@@ -1,6 +1,7 @@
 package mistral
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 )
@@ -38,8 +39,14 @@ type ModelList struct {
 	Data   []ModelCard `json:"data"`
 }
 
+// ListModels returns the models available to the API key.
 func (c *MistralClient) ListModels() (*ModelList, error) {
-	response, err := c.request(http.MethodGet, nil, "v1/models", false, nil)
+	return c.ListModelsContext(context.Background())
+}
+
+// ListModelsContext is like ListModels but the request, including any retries, is bound to the given context.
+func (c *MistralClient) ListModelsContext(ctx context.Context) (*ModelList, error) {
+	response, err := c.request(ctx, http.MethodGet, nil, "v1/models", false, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -57,3 +64,26 @@ func (c *MistralClient) ListModels() (*ModelList, error) {
 
 	return &modelList, nil
 }
+
+// modelContextWindows holds the context window, in tokens, of the models with constants in this package.
+var modelContextWindows = map[string]int{
+	ModelMistralLargeLatest:  131072,
+	ModelMistralMediumLatest: 131072,
+	ModelMistralSmallLatest:  131072,
+	ModelCodestralLatest:     262144,
+	ModelOpenMixtral8x7b:     32768,
+	ModelOpenMixtral8x22b:    65536,
+	ModelOpenMistral7b:       32768,
+	ModelMistralLarge2402:    32768,
+	ModelMistralMedium2312:   32768,
+	ModelMistralSmall2402:    32768,
+	ModelMistralSmall2312:    32768,
+	ModelMistralTiny:         32768,
+}
+
+// ContextWindow returns the maximum number of tokens, prompt and completion together, that a model accepts.
+// It reports false for models it does not know.
+func ContextWindow(model string) (int, bool) {
+	n, ok := modelContextWindows[model]
+	return n, ok
+}
@@ -0,0 +1,84 @@
+package mistral
+
+import (
+	"net/http"
+	"time"
+)
+
+// OrganizationHeader is the header used to scope requests to an organization when WithOrganization is set.
+const OrganizationHeader = "Mistral-Organization"
+
+// ClientOption configures a MistralClient created with NewMistralClientWithOptions.
+type ClientOption func(*MistralClient)
+
+// WithHTTPClient sets the http.Client used for every request, allowing a connection pool, proxy or mTLS
+// configuration to be shared. WithTimeout has no effect on a client provided this way.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *MistralClient) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTransport sets the http.RoundTripper used for every request. When combined with WithHTTPClient the
+// provided client is copied and its transport replaced.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *MistralClient) {
+		c.transport = transport
+	}
+}
+
+// WithBaseURL sets the API endpoint, e.g. CodestralEndpoint or the address of a proxy. An empty URL keeps the default.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *MistralClient) {
+		if baseURL != "" {
+			c.endpoint = baseURL
+		}
+	}
+}
+
+// WithHeaders adds headers to every request. Authorization and Content-Type are always set by the client.
+func WithHeaders(headers http.Header) ClientOption {
+	return func(c *MistralClient) {
+		for key, values := range headers {
+			for _, value := range values {
+				c.headers.Add(key, value)
+			}
+		}
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *MistralClient) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithOrganization sets the organization sent in the OrganizationHeader of every request.
+func WithOrganization(organization string) ClientOption {
+	return func(c *MistralClient) {
+		c.organization = organization
+	}
+}
+
+// WithTimeout sets the timeout of the http.Client created by the constructor.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *MistralClient) {
+		c.timeout = timeout
+	}
+}
+
+// WithToolResultValidation makes Chat and the chat streams check the messages with ValidateToolResults before
+// sending them, returning an error wrapping ErrToolResultMismatch instead of making a request the API would reject.
+func WithToolResultValidation() ClientOption {
+	return func(c *MistralClient) {
+		c.validateToolResults = true
+	}
+}
+
+// WithRetryPolicy sets the policy used to retry failed requests.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *MistralClient) {
+		c.retryPolicy = policy
+	}
+}
@@ -0,0 +1,377 @@
+package mistral
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// PartialJSONEvent reports a value of a streamed JSON document that has been received completely.
+type PartialJSONEvent struct {
+	Path  []any // The location of the value: object keys as strings and array indices as ints. Empty for the root.
+	Value any   // The decoded value, using the same types as encoding/json decoding into an interface.
+}
+
+// PathString renders the path of the event, e.g. "$.items[2].name".
+func (e PartialJSONEvent) PathString() string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, p := range e.Path {
+		switch p := p.(type) {
+		case int:
+			b.WriteString("[" + strconv.Itoa(p) + "]")
+		default:
+			b.WriteString("." + fmt.Sprint(p))
+		}
+	}
+	return b.String()
+}
+
+// PartialJSONParser incrementally parses a JSON document that arrives in fragments, such as the content deltas of a
+// chat stream using ResponseFormatJsonObject or ResponseFormatJsonSchema. Every fragment is processed once: Write
+// reports the values completed by the fragment and Value returns a best-effort view of the document so far.
+//
+// Anything before the first '{' or '[' (such as a markdown code fence) and anything after the document ends is
+// ignored. The zero value is ready to use.
+type PartialJSONParser struct {
+	stack    []*partialFrame
+	root     any
+	complete bool
+	err      error
+
+	// State of the token currently being read.
+	inString  bool
+	escaped   bool
+	scalar    []byte // raw bytes of the string (without the opening quote), number or literal being read
+	inScalar  bool
+	started   bool
+	events    []PartialJSONEvent
+	bytesRead int
+}
+
+type partialFrame struct {
+	isArray   bool
+	object    map[string]any
+	array     []any
+	key       string
+	hasKey    bool // whether the key of the next object value has been read
+	needValue bool // whether a value is expected next (after ':' in objects, after '[' or ',' in arrays)
+	needKey   bool // whether a key is expected next (after ',' in objects)
+	needComma bool // whether a member was completed, so only ',' or the closing bracket may follow
+	path      []any
+}
+
+// NewPartialJSONParser creates a parser for a new document.
+func NewPartialJSONParser() *PartialJSONParser {
+	return &PartialJSONParser{}
+}
+
+// Write feeds the next fragment of the document and returns the values completed by it, innermost values first.
+// Once the document is malformed Write returns nil and Err reports the syntax error.
+func (p *PartialJSONParser) Write(fragment string) []PartialJSONEvent {
+	p.events = nil
+	for i := 0; i < len(fragment) && p.err == nil && !p.complete; i++ {
+		p.feed(fragment[i])
+		p.bytesRead++
+	}
+	return p.events
+}
+
+// WriteChunk feeds the content delta of the first choice of a streamed chat response.
+func (p *PartialJSONParser) WriteChunk(chunk ChatCompletionStreamResponse) []PartialJSONEvent {
+	if len(chunk.Choices) == 0 {
+		return nil
+	}
+	return p.Write(chunk.Choices[0].Delta.Content)
+}
+
+// Complete reports whether the whole document has been received.
+func (p *PartialJSONParser) Complete() bool {
+	return p.complete
+}
+
+// Err returns the syntax error that stopped the parser, if any.
+func (p *PartialJSONParser) Err() error {
+	return p.err
+}
+
+// Value returns the document parsed so far. Open objects and arrays contain their completed members, and a string
+// that is still being received is included with the text received so far. Numbers and literals are only included
+// once complete. Value returns nil until the document has started.
+func (p *PartialJSONParser) Value() any {
+	if p.complete {
+		return p.root
+	}
+
+	var child any
+	hasChild := false
+	if p.inString && len(p.stack) > 0 {
+		top := p.stack[len(p.stack)-1]
+		if top.isArray || top.hasKey {
+			child = decodePartialString(p.scalar)
+			hasChild = true
+		}
+	}
+
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		f := p.stack[i]
+		var snapshot any
+		if f.isArray {
+			arr := make([]any, len(f.array), len(f.array)+1)
+			copy(arr, f.array)
+			if hasChild {
+				arr = append(arr, child)
+			}
+			snapshot = arr
+		} else {
+			obj := make(map[string]any, len(f.object)+1)
+			for k, v := range f.object {
+				obj[k] = v
+			}
+			if hasChild && f.hasKey {
+				obj[f.key] = child
+			}
+			snapshot = obj
+		}
+		child, hasChild = snapshot, true
+	}
+	return child
+}
+
+// PartialValueInto decodes the document parsed so far into T, see PartialJSONParser.Value.
+func PartialValueInto[T any](p *PartialJSONParser) (T, error) {
+	var v T
+	data, err := json.Marshal(p.Value())
+	if err != nil {
+		return v, err
+	}
+	err = json.Unmarshal(data, &v)
+	return v, err
+}
+
+func (p *PartialJSONParser) fail(format string, args ...any) {
+	p.err = fmt.Errorf("invalid JSON at byte %d: %s", p.bytesRead, fmt.Sprintf(format, args...))
+}
+
+func (p *PartialJSONParser) feed(b byte) {
+	if p.inString {
+		p.feedString(b)
+		return
+	}
+	if p.inScalar {
+		if isScalarByte(b) {
+			p.scalar = append(p.scalar, b)
+			return
+		}
+		p.finishScalar()
+		if p.err != nil {
+			return
+		}
+	}
+
+	if !p.started {
+		if b == '{' || b == '[' {
+			p.started = true
+			p.open(b == '[')
+		}
+		return
+	}
+
+	switch b {
+	case ' ', '\t', '\n', '\r':
+	case '{', '[':
+		if !p.expectingValue() {
+			p.fail("unexpected %q", b)
+			return
+		}
+		p.open(b == '[')
+	case '}', ']':
+		p.close(b == ']')
+	case ':':
+		top := p.top()
+		if top.isArray || !top.hasKey || top.needValue {
+			p.fail("unexpected ':'")
+			return
+		}
+		top.needValue = true
+	case ',':
+		top := p.top()
+		if !top.needComma {
+			p.fail("unexpected ','")
+			return
+		}
+		top.needComma = false
+		if top.isArray {
+			top.needValue = true
+		} else {
+			top.needKey = true
+		}
+	case '"':
+		top := p.top()
+		isKey := !top.isArray && !top.hasKey
+		if (isKey && top.needComma) || (!isKey && !p.expectingValue()) {
+			p.fail("unexpected string")
+			return
+		}
+		p.inString = true
+		p.scalar = p.scalar[:0]
+	default:
+		if !isScalarByte(b) || !p.expectingValue() {
+			p.fail("unexpected %q", b)
+			return
+		}
+		p.inScalar = true
+		p.scalar = append(p.scalar[:0], b)
+	}
+}
+
+func (p *PartialJSONParser) feedString(b byte) {
+	if p.escaped {
+		p.escaped = false
+		p.scalar = append(p.scalar, b)
+		return
+	}
+	switch b {
+	case '\\':
+		p.escaped = true
+		p.scalar = append(p.scalar, b)
+	case '"':
+		p.inString = false
+		var s string
+		if err := json.Unmarshal(append(append([]byte{'"'}, p.scalar...), '"'), &s); err != nil {
+			p.fail("invalid string: %v", err)
+			return
+		}
+		top := p.top()
+		if !top.isArray && !top.hasKey {
+			top.key = s
+			top.hasKey = true
+			top.needKey = false
+			return
+		}
+		p.addValue(s)
+	default:
+		p.scalar = append(p.scalar, b)
+	}
+}
+
+func (p *PartialJSONParser) finishScalar() {
+	p.inScalar = false
+	var v any
+	if err := json.Unmarshal(p.scalar, &v); err != nil {
+		p.fail("invalid value %q", p.scalar)
+		return
+	}
+	p.addValue(v)
+}
+
+func (p *PartialJSONParser) top() *partialFrame {
+	return p.stack[len(p.stack)-1]
+}
+
+// expectingValue reports whether the next token must be a value.
+func (p *PartialJSONParser) expectingValue() bool {
+	return len(p.stack) > 0 && p.top().needValue
+}
+
+// childPath returns the path of the next value added to the top frame.
+func (p *PartialJSONParser) childPath() []any {
+	if len(p.stack) == 0 {
+		return nil
+	}
+	top := p.top()
+	path := make([]any, len(top.path), len(top.path)+1)
+	copy(path, top.path)
+	if top.isArray {
+		return append(path, len(top.array))
+	}
+	return append(path, top.key)
+}
+
+func (p *PartialJSONParser) open(isArray bool) {
+	f := &partialFrame{isArray: isArray, path: p.childPath(), needValue: isArray}
+	if !isArray {
+		f.object = map[string]any{}
+	}
+	p.stack = append(p.stack, f)
+}
+
+func (p *PartialJSONParser) close(isArray bool) {
+	top := p.top()
+	if top.isArray != isArray {
+		p.fail("mismatched closing bracket")
+		return
+	}
+	// A value is still expected after ':' or ','; an empty array is the only exception.
+	if (top.isArray && top.needValue && len(top.array) > 0) || (!top.isArray && (top.hasKey || top.needKey)) {
+		p.fail("unexpected closing bracket")
+		return
+	}
+
+	p.stack = p.stack[:len(p.stack)-1]
+	var v any = top.object
+	if top.isArray {
+		if top.array == nil {
+			top.array = []any{}
+		}
+		v = top.array
+	}
+	p.events = append(p.events, PartialJSONEvent{Path: top.path, Value: v})
+
+	if len(p.stack) == 0 {
+		p.root = v
+		p.complete = true
+		return
+	}
+	p.attach(v)
+}
+
+// addValue adds a completed scalar to the top frame.
+func (p *PartialJSONParser) addValue(v any) {
+	p.events = append(p.events, PartialJSONEvent{Path: p.childPath(), Value: v})
+	p.attach(v)
+}
+
+func (p *PartialJSONParser) attach(v any) {
+	top := p.top()
+	if top.isArray {
+		top.array = append(top.array, v)
+	} else {
+		top.object[top.key] = v
+		top.key = ""
+		top.hasKey = false
+	}
+	top.needValue = false
+	top.needComma = true
+}
+
+func isScalarByte(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || b == '-' || b == '+' || b == '.' || b == 'E'
+}
+
+// decodePartialString decodes the raw bytes of a string that is still being received, dropping a trailing
+// incomplete escape sequence or UTF-8 character.
+func decodePartialString(raw []byte) string {
+	// Escapes are walked from the start, since a backslash may itself be escaped.
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' {
+			continue
+		}
+		if i+1 == len(raw) || (raw[i+1] == 'u' && i+6 > len(raw)) {
+			raw = raw[:i]
+			break
+		}
+		i++
+	}
+	for len(raw) > 0 && !utf8.Valid(raw) {
+		raw = raw[:len(raw)-1]
+	}
+
+	var s string
+	if err := json.Unmarshal(append(append([]byte{'"'}, raw...), '"'), &s); err != nil {
+		return string(raw)
+	}
+	return s
+}
@@ -0,0 +1,135 @@
+package mistral
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartialJSONParserEvents(t *testing.T) {
+	p := NewPartialJSONParser()
+
+	var paths []string
+	for _, fragment := range []string{`{"ti`, `tle": "Gro`, `ceries", "items": [{"name": "mi`, `lk", "qty": 2`, `}, {"name": "eggs", "qty": 12}], "done": tr`, `ue}`} {
+		for _, e := range p.Write(fragment) {
+			paths = append(paths, e.PathString())
+		}
+	}
+
+	assert.Equal(t, []string{
+		"$.title",
+		"$.items[0].name",
+		"$.items[0].qty",
+		"$.items[0]",
+		"$.items[1].name",
+		"$.items[1].qty",
+		"$.items[1]",
+		"$.items",
+		"$.done",
+		"$",
+	}, paths)
+	assert.True(t, p.Complete())
+	assert.NoError(t, p.Err())
+
+	var expected any
+	assert.NoError(t, json.Unmarshal([]byte(`{"title":"Groceries","items":[{"name":"milk","qty":2},{"name":"eggs","qty":12}],"done":true}`), &expected))
+	assert.Equal(t, expected, p.Value())
+}
+
+func TestPartialJSONParserValue(t *testing.T) {
+	p := NewPartialJSONParser()
+	assert.Nil(t, p.Value())
+
+	p.Write(`{"items": [{"name": "milk"}, {"name": "egg`)
+	assert.Equal(t, map[string]any{
+		"items": []any{
+			map[string]any{"name": "milk"},
+			map[string]any{"name": "egg"},
+		},
+	}, p.Value())
+
+	// Numbers are only reported once they are known to be complete.
+	p.Write(`s", "qty": 1`)
+	assert.Equal(t, map[string]any{"name": "eggs"}, p.Value().(map[string]any)["items"].([]any)[1])
+
+	events := p.Write(`2}`)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, []any{"items", 1, "qty"}, events[0].Path)
+		assert.Equal(t, float64(12), events[0].Value)
+	}
+}
+
+func TestPartialJSONParserPartialString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain", `["hello wor`, "hello wor"},
+		{"escape", `["line\nbreak`, "line\nbreak"},
+		{"dangling backslash", `["quote \`, "quote "},
+		{"partial unicode escape", `["caf\u00`, "caf"},
+		{"partial utf8", "[\"caf\xc3", "caf"},
+		{"escaped backslash", `["dir\\`, `dir\`},
+		{"escaped backslash before u", `["dir\\u00`, `dir\u00`},
+		{"escaped backslash before partial escape", `["dir\\\u00`, `dir\`},
+		{"escaped backslash before dangling backslash", `["dir\\\`, `dir\`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPartialJSONParser()
+			p.Write(tt.input)
+			assert.Equal(t, []any{tt.expected}, p.Value())
+		})
+	}
+}
+
+func TestPartialJSONParserSkipsSurroundingText(t *testing.T) {
+	p := NewPartialJSONParser()
+	p.Write("```json\n{\"a\": [1, 2]}\n```")
+
+	assert.True(t, p.Complete())
+	assert.NoError(t, p.Err())
+	assert.Equal(t, map[string]any{"a": []any{float64(1), float64(2)}}, p.Value())
+}
+
+func TestPartialJSONParserSyntaxError(t *testing.T) {
+	for _, input := range []string{`{"a" 1}`, `{"a": 1]`, `[1 2]`, `{"a": tru }`, `{"a": }`, `{"a":1 "b":2}`, `{,"a":1}`, `{"a":1,}`, `{"a":1,,"b":2}`, `[,1]`, `[1,]`} {
+		p := NewPartialJSONParser()
+		p.Write(input)
+		assert.Error(t, p.Err(), input)
+		assert.False(t, p.Complete(), input)
+		assert.Nil(t, p.Write(`}`), input)
+	}
+}
+
+func TestPartialJSONParserByteAtATime(t *testing.T) {
+	doc := `{"nested": {"list": [[], {}, "x", -1.5e3, null, false]}, "s": "\"q\" é"}`
+	p := NewPartialJSONParser()
+	for i := 0; i < len(doc); i++ {
+		p.Write(doc[i : i+1])
+	}
+
+	var expected any
+	assert.NoError(t, json.Unmarshal([]byte(doc), &expected))
+	assert.True(t, p.Complete())
+	assert.Equal(t, expected, p.Value())
+}
+
+func TestPartialJSONParserWriteChunk(t *testing.T) {
+	p := NewPartialJSONParser()
+	for _, content := range []string{`{"tags": ["a"`, `, "b"]`, `}`} {
+		p.WriteChunk(ChatCompletionStreamResponse{
+			Choices: []ChatCompletionResponseChoiceStream{{Delta: DeltaMessage{Content: content}}},
+		})
+	}
+	assert.True(t, p.Complete())
+
+	type tagged struct {
+		Tags []string `json:"tags"`
+	}
+	v, err := PartialValueInto[tagged](p)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, v.Tags)
+}
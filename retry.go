@@ -0,0 +1,182 @@
+package mistral
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+	DefaultRetryMaxDelay  = 30 * time.Second
+	DefaultRetryJitter    = 0.2
+)
+
+var retryStatusCodes = map[int]bool{
+	429: true,
+	500: true,
+	502: true,
+	503: true,
+	504: true,
+}
+
+// RetryPolicy controls how failed requests are retried. Retries are only attempted for retryable HTTP statuses and,
+// when enabled, for connection errors such as resets and timeouts. Cancelling the request context stops retrying.
+type RetryPolicy struct {
+	MaxAttempts           int                   // Total number of attempts including the first one. Values below 1 are treated as 1.
+	BaseDelay             time.Duration         // Delay before the first retry, doubled on every subsequent retry. Defaults to DefaultRetryBaseDelay.
+	MaxDelay              time.Duration         // Upper bound for any single delay, including server provided ones. Defaults to DefaultRetryMaxDelay.
+	Jitter                float64               // Fraction (0-1) of each delay that is randomized to spread out concurrent retries. Zero disables jitter.
+	RetryableStatus       func(status int) bool // Reports whether a response status should be retried. Defaults to 429, 500, 502, 503 and 504.
+	RetryConnectionErrors bool                  // Retry when the connection is reset, refused or times out.
+	OnRetry               func(RetryEvent)      // Called before sleeping ahead of every retry.
+}
+
+// RetryEvent describes a failed attempt that is about to be retried.
+type RetryEvent struct {
+	Attempt    int           // The attempt that failed, starting at 1.
+	Reason     string        // Human readable reason for the retry.
+	StatusCode int           // The HTTP status of the failed attempt, zero for connection errors.
+	Err        error         // The connection error of the failed attempt, nil for HTTP errors.
+	Delay      time.Duration // How long the client waits before the next attempt.
+}
+
+// DefaultRetryPolicy returns the retry policy used when none is configured.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:           DefaultMaxRetries,
+		BaseDelay:             DefaultRetryBaseDelay,
+		MaxDelay:              DefaultRetryMaxDelay,
+		Jitter:                DefaultRetryJitter,
+		RetryConnectionErrors: true,
+	}
+}
+
+func (p RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p RetryPolicy) isRetryableStatus(status int) bool {
+	if p.RetryableStatus != nil {
+		return p.RetryableStatus(status)
+	}
+	return retryStatusCodes[status]
+}
+
+// retryReason returns why the outcome of an attempt should be retried, or an empty string when it should not.
+func (p RetryPolicy) retryReason(ctx context.Context, resp *http.Response, err error) string {
+	if ctx.Err() != nil {
+		return ""
+	}
+	if err != nil {
+		if p.RetryConnectionErrors && isRetryableConnectionError(err) {
+			return fmt.Sprintf("connection error: %v", err)
+		}
+		return ""
+	}
+	if p.isRetryableStatus(resp.StatusCode) {
+		return fmt.Sprintf("HTTP status %d", resp.StatusCode)
+	}
+	return ""
+}
+
+// delay returns how long to wait after the given failed attempt. Server provided delays take precedence over
+// the exponential backoff when they are longer.
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = DefaultRetryBaseDelay
+	}
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = DefaultRetryMaxDelay
+	}
+
+	backoff := float64(base) * math.Pow(2, float64(attempt-1))
+	if backoff > float64(maxDelay) {
+		backoff = float64(maxDelay)
+	}
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		backoff -= backoff * jitter * rand.Float64()
+	}
+	d := time.Duration(backoff)
+
+	if resp != nil {
+		if serverDelay, ok := parseRetryAfter(resp.Header, time.Now()); ok && serverDelay > d {
+			d = serverDelay
+		}
+	}
+	if d > maxDelay {
+		d = maxDelay
+	}
+	return d
+}
+
+// parseRetryAfter reads the delay requested by the server from the Retry-After, Retry-After-Ms or
+// X-RateLimit-Reset headers.
+func parseRetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	if v := header.Get("Retry-After-Ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms >= 0 {
+			return time.Duration(ms * float64(time.Millisecond)), true
+		}
+	}
+	if v := header.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.ParseFloat(v, 64); err == nil && seconds >= 0 {
+			return time.Duration(seconds * float64(time.Second)), true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return nonNegative(t.Sub(now)), true
+		}
+	}
+	if v := header.Get("X-RateLimit-Reset"); v != "" {
+		if seconds, err := strconv.ParseFloat(v, 64); err == nil && seconds >= 0 {
+			// Large values are absolute unix timestamps rather than relative delays.
+			if seconds > 1e9 {
+				return nonNegative(time.Unix(int64(seconds), 0).Sub(now)), true
+			}
+			return time.Duration(seconds * float64(time.Second)), true
+		}
+	}
+	return 0, false
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// isRetryableConnectionError reports whether err is a transient transport failure.
+func isRetryableConnectionError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// drainAndClose discards the rest of a response body so the connection can be reused.
+func drainAndClose(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, 64<<10))
+	_ = body.Close()
+}
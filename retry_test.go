@@ -0,0 +1,111 @@
+package mistral
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryReplaysBody(t *testing.T) {
+	var attempts int32
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"id":"1","data":[{"object":"embedding","embedding":[0.5],"index":0}]}`))
+	})
+
+	var events []RetryEvent
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.OnRetry = func(e RetryEvent) {
+		events = append(events, e)
+	}
+	client := srv.Client(WithRetryPolicy(policy))
+
+	res, err := client.Embeddings("mistral-embed", []string{"hello"})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.5}, res.Data[0].Embedding)
+
+	requests := srv.Requests()
+	assert.Len(t, requests, 3)
+	for _, request := range requests {
+		assert.Equal(t, requests[0].Body, request.Body)
+	}
+	assert.NotEmpty(t, requests[0].Body)
+
+	assert.Len(t, events, 2)
+	assert.Equal(t, 1, events[0].Attempt)
+	assert.Equal(t, 2, events[1].Attempt)
+	assert.Equal(t, http.StatusTooManyRequests, events[0].StatusCode)
+}
+
+func TestRetryExhausted(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("bad gateway"))
+	})
+
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	client := srv.Client(WithRetryPolicy(policy))
+
+	_, err := client.ListModels()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "after 3 attempts")
+	assert.Len(t, srv.Requests(), 3)
+}
+
+func TestRetryNonRetryableStatus(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	client := srv.Client()
+	_, err := client.ListModels()
+	assert.Error(t, err)
+	assert.Len(t, srv.Requests(), 1)
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	assert.Equal(t, 100*time.Millisecond, policy.delay(1, nil))
+	assert.Equal(t, 400*time.Millisecond, policy.delay(3, nil))
+	assert.Equal(t, time.Second, policy.delay(10, nil))
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"0.5"}}}
+	assert.Equal(t, 500*time.Millisecond, policy.delay(1, resp))
+
+	resp = &http.Response{Header: http.Header{"Retry-After": []string{"120"}}}
+	assert.Equal(t, time.Second, policy.delay(1, resp))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 20; i++ {
+		d := policy.delay(2, nil)
+		assert.GreaterOrEqual(t, d, 100*time.Millisecond)
+		assert.LessOrEqual(t, d, 200*time.Millisecond)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	d, ok := parseRetryAfter(http.Header{"Retry-After": []string{now.Add(3 * time.Second).Format(http.TimeFormat)}}, now)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, d)
+
+	d, ok = parseRetryAfter(http.Header{"Retry-After-Ms": []string{"250"}}, now)
+	assert.True(t, ok)
+	assert.Equal(t, 250*time.Millisecond, d)
+
+	d, ok = parseRetryAfter(http.Header{"X-Ratelimit-Reset": []string{"2"}}, now)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, d)
+
+	_, ok = parseRetryAfter(http.Header{}, now)
+	assert.False(t, ok)
+}
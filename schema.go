@@ -0,0 +1,320 @@
+package mistral
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	byteSliceType  = reflect.TypeOf([]byte{})
+)
+
+// JSONSchemaFor returns the JSON schema describing values of type T, suitable for Function.Parameters.
+// See JSONSchemaOf for how Go types and struct tags are mapped.
+func JSONSchemaFor[T any]() (map[string]any, error) {
+	return JSONSchemaOf(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// JSONSchemaOf returns the JSON schema describing values of type t, as they are encoded by encoding/json.
+//
+// Structs become objects whose properties are named after their json tags and which allow no additional
+// properties. Fields are required unless they are pointers or tagged omitempty; the `required:"true"` and
+// `required:"false"` tags override this. The `description`, `enum` (comma separated), `minimum`, `maximum` and
+// `pattern` tags add the matching schema keywords; on slice and array fields all but description apply to the items.
+// Slices and arrays become arrays, maps with string keys become objects with additionalProperties, pointers are
+// described by their element type and also accept null, and time.Time is a date-time string. Recursive types are not
+// supported.
+func JSONSchemaOf(t reflect.Type) (map[string]any, error) {
+	g := schemaGenerator{seen: map[reflect.Type]bool{}}
+	return g.schema(t)
+}
+
+type schemaGenerator struct {
+	seen map[reflect.Type]bool
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) (map[string]any, error) {
+	if t.Kind() == reflect.Pointer {
+		s, err := g.schema(derefType(t))
+		if err != nil {
+			return nil, err
+		}
+		// encoding/json encodes nil pointers as null.
+		if typ, ok := s["type"].(string); ok {
+			s["type"] = []string{typ, "null"}
+		}
+		return s, nil
+	}
+
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case rawMessageType:
+		return map[string]any{}, nil
+	case byteSliceType:
+		return map[string]any{"type": "string", "contentEncoding": "base64"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]any{"type": "integer", "minimum": 0}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s := map[string]any{"type": "array", "items": items}
+		if t.Kind() == reflect.Array {
+			s["minItems"] = t.Len()
+			s["maxItems"] = t.Len()
+		}
+		return s, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s: JSON object keys must be strings", t.Key())
+		}
+		values, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		return g.structSchema(t)
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) (map[string]any, error) {
+	if g.seen[t] {
+		return nil, fmt.Errorf("recursive type %s is not supported", t)
+	}
+	g.seen[t] = true
+	defer delete(g.seen, t)
+
+	properties := map[string]any{}
+	required := []string{}
+	if err := g.addFields(t, properties, &required); err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}, nil
+}
+
+// addFields adds the properties of the fields of t, flattening embedded structs like encoding/json does.
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]any, required *[]string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := g.addFields(ft, properties, required); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		s, err := g.schema(field.Type)
+		if err != nil {
+			return fmt.Errorf("field %s.%s: %w", t.Name(), field.Name, err)
+		}
+		if err := applySchemaTags(s, field); err != nil {
+			return fmt.Errorf("field %s.%s: %w", t.Name(), field.Name, err)
+		}
+		properties[name] = s
+
+		isRequired := field.Type.Kind() != reflect.Pointer && !strings.Contains(","+opts+",", ",omitempty,")
+		if v, ok := field.Tag.Lookup("required"); ok {
+			isRequired = v == "true"
+		}
+		if isRequired {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+// applySchemaTags adds the keywords of the description, enum, minimum, maximum and pattern struct tags. The keywords
+// constraining values go on the items of slices and arrays, since they describe the elements.
+func applySchemaTags(s map[string]any, field reflect.StructField) error {
+	if v, ok := field.Tag.Lookup("description"); ok {
+		s["description"] = v
+	}
+
+	if items, ok := s["items"].(map[string]any); ok {
+		s = items
+	}
+	if v, ok := field.Tag.Lookup("enum"); ok {
+		typ, nullable := schemaTypeName(s["type"])
+		var values []any
+		for _, raw := range strings.Split(v, ",") {
+			value, err := parseEnumValue(typ, strings.TrimSpace(raw))
+			if err != nil {
+				return err
+			}
+			values = append(values, value)
+		}
+		if nullable {
+			values = append(values, nil)
+		}
+		s["enum"] = values
+	}
+	for _, keyword := range []string{"minimum", "maximum"} {
+		if v, ok := field.Tag.Lookup(keyword); ok {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q: %w", keyword, v, err)
+			}
+			s[keyword] = n
+		}
+	}
+	if v, ok := field.Tag.Lookup("pattern"); ok {
+		s["pattern"] = v
+	}
+	return nil
+}
+
+// derefType returns the type t points to, through any number of pointers.
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// schemaTypeName returns the type named by a generated type keyword and whether it also allows null.
+func schemaTypeName(schemaType any) (string, bool) {
+	switch t := schemaType.(type) {
+	case string:
+		return t, false
+	case []string:
+		return t[0], len(t) > 1 && t[len(t)-1] == "null"
+	}
+	return "", false
+}
+
+// parseEnumValue converts an enum tag value to the JSON type of the field.
+func parseEnumValue(schemaType string, raw string) (any, error) {
+	switch schemaType {
+	case "integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer enum value %q", raw)
+		}
+		return n, nil
+	case "number":
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number enum value %q", raw)
+		}
+		return n, nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean enum value %q", raw)
+		}
+		return b, nil
+	}
+	return raw, nil
+}
+
+// FunctionTool is a Function whose parameters are described by the Go type T.
+type FunctionTool[T any] struct {
+	Function Function
+}
+
+// NewFunctionTool creates a function tool whose parameters schema is generated from T, which should be a struct.
+func NewFunctionTool[T any](name string, description string) (*FunctionTool[T], error) {
+	parameters, err := JSONSchemaFor[T]()
+	if err != nil {
+		return nil, fmt.Errorf("error generating parameters schema for %s: %w", name, err)
+	}
+
+	return &FunctionTool[T]{
+		Function: Function{
+			Name:        name,
+			Description: description,
+			Parameters:  parameters,
+		},
+	}, nil
+}
+
+// Tool returns the tool definition to set on ChatRequestParams.Tools.
+func (f *FunctionTool[T]) Tool() Tool {
+	return Tool{Type: ToolTypeFunction, Function: f.Function}
+}
+
+// Decode decodes the arguments of a call to the function into T.
+func (f *FunctionTool[T]) Decode(call FunctionCall) (T, error) {
+	var args T
+	if call.Name != f.Function.Name {
+		return args, fmt.Errorf("cannot decode call to %s as arguments of %s", call.Name, f.Function.Name)
+	}
+	// Like ValidateToolArguments, blank arguments are an empty object: models omit them for calls without parameters.
+	arguments := call.Arguments
+	if strings.TrimSpace(arguments) == "" {
+		arguments = "{}"
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return args, fmt.Errorf("error decoding arguments of %s: %w", f.Function.Name, err)
+	}
+	return args, nil
+}
+
+// Handler adapts a function taking decoded arguments into a ToolHandler for ToolRegistry.Register.
+func (f *FunctionTool[T]) Handler(fn func(ctx context.Context, args T) (any, error)) ToolHandler {
+	return func(ctx context.Context, call ToolCall) (any, error) {
+		args, err := f.Decode(call.Function)
+		if err != nil {
+			return nil, err
+		}
+		return fn(ctx, args)
+	}
+}
+
+// RegisterFunction creates a function tool from T and registers fn as its handler.
+func RegisterFunction[T any](registry *ToolRegistry, name string, description string, fn func(ctx context.Context, args T) (any, error), opts ...ToolOption) error {
+	tool, err := NewFunctionTool[T](name, description)
+	if err != nil {
+		return err
+	}
+	return registry.Register(tool.Function, tool.Handler(fn), opts...)
+}
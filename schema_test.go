@@ -0,0 +1,167 @@
+package mistral
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type schemaAddress struct {
+	City  string `json:"city" description:"Name of the city"`
+	State string `json:"state" description:"Two letter state code" pattern:"^[A-Z]{2}$"`
+}
+
+type schemaBase struct {
+	ID string `json:"id"`
+}
+
+type schemaParams struct {
+	schemaBase
+	Address   schemaAddress     `json:"address"`
+	Unit      string            `json:"unit,omitempty" enum:"celsius,fahrenheit"`
+	Days      int               `json:"days" minimum:"1" maximum:"14"`
+	Priority  int               `json:"priority" enum:"1,2,3" required:"false"`
+	Tags      []string          `json:"tags"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Threshold *float64          `json:"threshold"`
+	Since     time.Time         `json:"since" required:"true"`
+	Extra     any               `json:"extra,omitempty"`
+	Count     uint8             `json:"count"`
+	Pair      [2]bool           `json:"pair"`
+	Ignored   string            `json:"-"`
+	internal  string
+}
+
+func TestJSONSchemaFor(t *testing.T) {
+	schema, err := JSONSchemaFor[schemaParams]()
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(schema)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["id", "address", "days", "tags", "since", "count", "pair"],
+		"properties": {
+			"id": {"type": "string"},
+			"address": {
+				"type": "object",
+				"additionalProperties": false,
+				"required": ["city", "state"],
+				"properties": {
+					"city": {"type": "string", "description": "Name of the city"},
+					"state": {"type": "string", "description": "Two letter state code", "pattern": "^[A-Z]{2}$"}
+				}
+			},
+			"unit": {"type": "string", "enum": ["celsius", "fahrenheit"]},
+			"days": {"type": "integer", "minimum": 1, "maximum": 14},
+			"priority": {"type": "integer", "enum": [1, 2, 3]},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"threshold": {"type": ["number", "null"]},
+			"since": {"type": "string", "format": "date-time"},
+			"extra": {},
+			"count": {"type": "integer", "minimum": 0},
+			"pair": {"type": "array", "items": {"type": "boolean"}, "minItems": 2, "maxItems": 2}
+		}
+	}`, string(data))
+}
+
+type schemaTagged struct {
+	Tags    []string        `json:"tags" enum:"a,b" description:"Tags of the item"`
+	Scores  [2]int          `json:"scores" minimum:"0" maximum:"10"`
+	Note    *string         `json:"note"`
+	Level   *int            `json:"level" enum:"1,2"`
+	Options []*string       `json:"options,omitempty"`
+	Raw     json.RawMessage `json:"raw,omitempty" description:"Anything"`
+}
+
+func TestJSONSchemaForTags(t *testing.T) {
+	schema, err := JSONSchemaFor[schemaTagged]()
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(schema)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["tags", "scores"],
+		"properties": {
+			"tags": {"type": "array", "description": "Tags of the item", "items": {"type": "string", "enum": ["a", "b"]}},
+			"scores": {"type": "array", "items": {"type": "integer", "minimum": 0, "maximum": 10}, "minItems": 2, "maxItems": 2},
+			"note": {"type": ["string", "null"]},
+			"level": {"type": ["integer", "null"], "enum": [1, 2, null]},
+			"options": {"type": "array", "items": {"type": ["string", "null"]}},
+			"raw": {"description": "Anything"}
+		}
+	}`, string(data))
+
+	assert.NoError(t, ValidateJSON(schema, []byte(`{"tags":["a"],"scores":[0,10],"note":null,"level":null,"options":["x",null]}`)))
+	assert.NoError(t, ValidateJSON(schema, []byte(`{"tags":[],"scores":[1,2],"note":"n","level":2}`)))
+	err = ValidateJSON(schema, []byte(`{"tags":["c"],"scores":[1,11],"level":3}`))
+	var validationErr *SchemaValidationError
+	if assert.ErrorAs(t, err, &validationErr) {
+		assert.Equal(t, []SchemaViolation{
+			{Path: "$.level", Message: "value must be one of [1,2,null]"},
+			{Path: "$.scores[1]", Message: "value must be <= 10"},
+			{Path: "$.tags[0]", Message: `value must be one of ["a","b"]`},
+		}, validationErr.Violations)
+	}
+}
+
+type schemaRecursive struct {
+	Children []schemaRecursive `json:"children"`
+}
+
+func TestJSONSchemaForUnsupported(t *testing.T) {
+	_, err := JSONSchemaFor[schemaRecursive]()
+	assert.ErrorContains(t, err, "recursive")
+
+	_, err = JSONSchemaFor[map[int]string]()
+	assert.Error(t, err)
+
+	_, err = JSONSchemaFor[chan int]()
+	assert.Error(t, err)
+
+	_, err = JSONSchemaFor[struct {
+		Days int `json:"days" enum:"one"`
+	}]()
+	assert.Error(t, err)
+}
+
+type weatherArgs struct {
+	City  string `json:"city" description:"Name of the city for the weather"`
+	State string `json:"state" description:"Name of the state for the weather"`
+}
+
+func TestFunctionTool(t *testing.T) {
+	tool, err := NewFunctionTool[weatherArgs]("get_weather", "Retrieve the weather for a city in the US")
+	assert.NoError(t, err)
+	assert.Equal(t, ToolTypeFunction, tool.Tool().Type)
+	assert.Equal(t, "get_weather", tool.Tool().Function.Name)
+
+	args, err := tool.Decode(FunctionCall{Name: "get_weather", Arguments: `{"city": "Dallas", "state": "TX"}`})
+	assert.NoError(t, err)
+	assert.Equal(t, weatherArgs{City: "Dallas", State: "TX"}, args)
+
+	_, err = tool.Decode(FunctionCall{Name: "send_text", Arguments: `{}`})
+	assert.Error(t, err)
+	_, err = tool.Decode(FunctionCall{Name: "get_weather", Arguments: `{"city":`})
+	assert.Error(t, err)
+
+	// Blank arguments decode like an empty object, matching ValidateToolArguments.
+	args, err = tool.Decode(FunctionCall{Name: "get_weather", Arguments: " "})
+	assert.NoError(t, err)
+	assert.Equal(t, weatherArgs{}, args)
+
+	registry := NewToolRegistry()
+	assert.NoError(t, RegisterFunction(registry, "get_weather", "Retrieve the weather", func(ctx context.Context, args weatherArgs) (any, error) {
+		return args.City + ", " + args.State, nil
+	}))
+	msg, err := registry.Execute(context.Background(), ToolCall{Id: "1", Function: FunctionCall{Name: "get_weather", Arguments: `{"city": "Dallas", "state": "TX"}`}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Dallas, TX", msg.Content)
+}
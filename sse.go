@@ -0,0 +1,154 @@
+package mistral
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// sseEvent is a single Server-Sent Event.
+type sseEvent struct {
+	Event string        // The event type, "message" unless the server set one.
+	Data  []byte        // The event data with multi-line data fields joined by "\n".
+	ID    string        // The last event id seen on the stream.
+	Retry time.Duration // The reconnection time requested by the server, zero if never set.
+}
+
+// sseDecoder reads Server-Sent Events as specified by the WHATWG HTML standard. Lines may end in "\r\n", "\n" or
+// "\r", comment lines are ignored and an event is dispatched on every blank line that follows at least one data field.
+type sseDecoder struct {
+	reader  *bufio.Reader
+	lastID  string
+	retry   time.Duration
+	started bool // Whether the leading byte order mark has been checked for.
+	skipLF  bool // Whether the last line ended in "\r", so a following "\n" belongs to it.
+}
+
+func newSSEDecoder(r io.Reader) *sseDecoder {
+	return &sseDecoder{reader: bufio.NewReader(r)}
+}
+
+// Next returns the next event on the stream. It returns io.EOF once the stream ends; a partially received event at
+// the end of the stream is discarded.
+func (d *sseDecoder) Next() (sseEvent, error) {
+	var (
+		event   string
+		data    bytes.Buffer
+		hasData bool
+	)
+
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			return sseEvent{}, err
+		}
+
+		// A blank line dispatches the event.
+		if len(line) == 0 {
+			if !hasData {
+				event = ""
+				continue
+			}
+			if event == "" {
+				event = "message"
+			}
+			return sseEvent{
+				Event: event,
+				Data:  bytes.TrimSuffix(data.Bytes(), []byte("\n")),
+				ID:    d.lastID,
+				Retry: d.retry,
+			}, nil
+		}
+
+		// Lines starting with a colon are comments, often used as keep-alives.
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value := line, []byte(nil)
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], line[i+1:]
+			value = bytes.TrimPrefix(value, []byte(" "))
+		}
+
+		switch string(field) {
+		case "event":
+			event = string(value)
+		case "data":
+			data.Write(value)
+			data.WriteByte('\n')
+			hasData = true
+		case "id":
+			if bytes.IndexByte(value, 0) == -1 {
+				d.lastID = string(value)
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(string(value), 10, 63); err == nil {
+				d.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// readLine reads a line terminated by "\r\n", "\n" or "\r" and returns it without the terminator. A final line
+// without a terminator is reported as io.EOF since the event it belongs to can never be dispatched. A "\r" ends the
+// line at once, without waiting for the next byte, and a "\n" read right after it is skipped by the next call.
+func (d *sseDecoder) readLine() ([]byte, error) {
+	if !d.started {
+		d.started = true
+		if bom, err := d.reader.Peek(3); err == nil && bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+			_, _ = d.reader.Discard(3)
+		}
+	}
+
+	var line []byte
+	for {
+		b, err := d.reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if d.skipLF {
+			d.skipLF = false
+			if b == '\n' {
+				continue
+			}
+		}
+		switch b {
+		case '\n':
+			return line, nil
+		case '\r':
+			d.skipLF = true
+			return line, nil
+		default:
+			line = append(line, b)
+		}
+	}
+}
+
+// sseStreamError returns the error sent by the server in place of a stream chunk, either as an "error" event or as
+// an error object in the data, or nil if the event carries a regular chunk. Only events whose data contains an
+// "error" string are decoded, so regular chunks are not decoded twice.
+func sseStreamError(event sseEvent) error {
+	if event.Event != "error" && !bytes.Contains(event.Data, []byte(`"error"`)) {
+		return nil
+	}
+
+	var body apiErrorBody
+	if err := json.Unmarshal(event.Data, &body); err != nil {
+		if event.Event == "error" {
+			return NewMistralAPIError(string(event.Data), 0, nil)
+		}
+		return nil
+	}
+	if event.Event != "error" && body.Object != "error" {
+		return nil
+	}
+
+	apiErr := NewMistralAPIError(string(event.Data), 0, nil)
+	apiErr.Body = string(event.Data)
+	apiErr.applyBody(body)
+	return apiErr
+}
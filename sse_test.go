@@ -0,0 +1,188 @@
+package mistral
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeAllSSE(t testing.TB, input string) []sseEvent {
+	t.Helper()
+	decoder := newSSEDecoder(strings.NewReader(input))
+	var events []sseEvent
+	for {
+		event, err := decoder.Next()
+		if errors.Is(err, io.EOF) {
+			return events
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		events = append(events, event)
+	}
+}
+
+func TestSSEDecoder(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		events []sseEvent
+	}{
+		{
+			name:   "single data line",
+			input:  "data: hello\n\n",
+			events: []sseEvent{{Event: "message", Data: []byte("hello")}},
+		},
+		{
+			name:   "multi-line data",
+			input:  "data: first\ndata: second\n\n",
+			events: []sseEvent{{Event: "message", Data: []byte("first\nsecond")}},
+		},
+		{
+			name:   "crlf and cr line endings",
+			input:  "data: a\r\n\r\ndata: b\r\rdata:c\n\n",
+			events: []sseEvent{{Event: "message", Data: []byte("a")}, {Event: "message", Data: []byte("b")}, {Event: "message", Data: []byte("c")}},
+		},
+		{
+			name:   "comments and unknown fields",
+			input:  ": keep-alive\nfoo: bar\ndata: x\n\n",
+			events: []sseEvent{{Event: "message", Data: []byte("x")}},
+		},
+		{
+			name:   "event id and retry",
+			input:  "event: error\nid: 7\nretry: 1500\ndata: {}\n\ndata: next\n\n",
+			events: []sseEvent{{Event: "error", Data: []byte("{}"), ID: "7", Retry: 1500 * time.Millisecond}, {Event: "message", Data: []byte("next"), ID: "7", Retry: 1500 * time.Millisecond}},
+		},
+		{
+			name:   "blank lines without data are skipped",
+			input:  "\n\nevent: ping\n\ndata: y\n\n",
+			events: []sseEvent{{Event: "message", Data: []byte("y")}},
+		},
+		{
+			name:   "field without colon",
+			input:  "data\n\n",
+			events: []sseEvent{{Event: "message", Data: []byte("")}},
+		},
+		{
+			name:   "leading byte order mark",
+			input:  "\xef\xbb\xbfdata: a\n\ndata: \xef\xbb\xbfb\n\n",
+			events: []sseEvent{{Event: "message", Data: []byte("a")}, {Event: "message", Data: []byte("\xef\xbb\xbfb")}},
+		},
+		{
+			name:   "unterminated event is discarded",
+			input:  "data: a\n\ndata: b\n",
+			events: []sseEvent{{Event: "message", Data: []byte("a")}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.events, decodeAllSSE(t, tt.input))
+		})
+	}
+}
+
+func TestSSEDecoderCRDoesNotWait(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	go w.Write([]byte("data: a\r\r"))
+
+	// The event is complete after the second "\r" and must be returned without waiting for more bytes.
+	done := make(chan sseEvent)
+	go func() {
+		event, err := newSSEDecoder(r).Next()
+		assert.NoError(t, err)
+		done <- event
+	}()
+	select {
+	case event := <-done:
+		assert.Equal(t, []byte("a"), event.Data)
+	case <-time.After(time.Second):
+		t.Fatal("event not dispatched until the next byte")
+	}
+}
+
+func TestSSEStreamError(t *testing.T) {
+	err := sseStreamError(sseEvent{Event: "message", Data: []byte(`{"object":"error","message":"Service unavailable","type":"internal_error","code":"3000"}`)})
+	var apiErr *MistralAPIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "Service unavailable", apiErr.Error())
+	assert.Equal(t, "3000", apiErr.Code)
+
+	err = sseStreamError(sseEvent{Event: "error", Data: []byte("upstream failure")})
+	assert.EqualError(t, err, "upstream failure")
+
+	err = sseStreamError(sseEvent{Data: []byte(`{"object": "error", "message": "Model overloaded"}`)})
+	assert.EqualError(t, err, "Model overloaded")
+
+	assert.NoError(t, sseStreamError(sseEvent{Event: "message", Data: []byte(`{"id":"1","choices":[]}`)}))
+	assert.NoError(t, sseStreamError(sseEvent{Data: []byte(`{"id":"1","choices":[{"index":0,"delta":{"content":"error"}}]}`)}))
+}
+
+func TestChatStreamReaderErrorEvent(t *testing.T) {
+	srv := newScriptedServer(t, testStream{
+		`{"id":"abc","choices":[{"index":0,"delta":{"content":"a"}}]}`,
+		`{"object":"error","message":"Model overloaded","type":"service_unavailable"}`,
+	})
+
+	client := srv.Client()
+	resChan, err := client.ChatStream(ModelMistralSmallLatest, nil, nil)
+	assert.NoError(t, err)
+
+	var chunks []ChatCompletionStreamResponse
+	for res := range resChan {
+		chunks = append(chunks, res)
+	}
+	assert.Len(t, chunks, 2)
+	assert.EqualError(t, chunks[1].Error, "Model overloaded")
+}
+
+func TestChatStreamReaderTruncated(t *testing.T) {
+	stream := newChatStreamReader(io.NopCloser(strings.NewReader("data: {\"id\":\"1\",\"choices\":[]}\n\ndata: {\"id\"")))
+	assert.True(t, stream.Next())
+	assert.False(t, stream.Next())
+	assert.ErrorIs(t, stream.Err(), io.ErrUnexpectedEOF)
+}
+
+func FuzzSSEDecoder(f *testing.F) {
+	f.Add("data: hello\n\n")
+	f.Add("data: a\ndata: b\r\n\r\n")
+	f.Add(": comment\nevent: error\nid: 1\nretry: 10\ndata: {}\n\n")
+	f.Add("data\r\rdata:\n\n\n")
+	f.Add("retry: 99999999999999999999999\nid: \x00\ndata: x\n\n")
+	f.Add("data: [DONE]\n\n")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		events := decodeAllSSE(t, input)
+		if len(events) > strings.Count(input, "\n")+strings.Count(input, "\r") {
+			t.Fatalf("decoded %d events from %q", len(events), input)
+		}
+		for _, event := range events {
+			if event.Event == "" {
+				t.Fatalf("event without type from %q", input)
+			}
+			if bytes.ContainsAny(event.Data, "\r") {
+				t.Fatalf("data contains a carriage return: %q", event.Data)
+			}
+			if event.Retry < 0 {
+				t.Fatalf("negative retry from %q", input)
+			}
+		}
+
+		// Line endings must not change the decoded events.
+		if !strings.Contains(input, "\r") {
+			crlf := decodeAllSSE(t, strings.ReplaceAll(input, "\n", "\r\n"))
+			assert.Equal(t, events, crlf)
+		}
+
+		// Malformed input must never break the stream reader either.
+		stream := newChatStreamReader(io.NopCloser(strings.NewReader(input)))
+		for stream.Next() {
+		}
+		_ = stream.Err()
+	})
+}
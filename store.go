@@ -0,0 +1,229 @@
+package mistral
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrConversationNotFound is returned by a ConversationStore for unknown conversation ids.
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// ConversationStore persists conversation records by id.
+type ConversationStore interface {
+	// Save stores the record, replacing any record with the same id.
+	Save(ctx context.Context, record ConversationRecord) error
+	// Load returns the record with the given id, or an error wrapping ErrConversationNotFound.
+	Load(ctx context.Context, id string) (ConversationRecord, error)
+	// Delete removes the record with the given id. Deleting an unknown id is not an error.
+	Delete(ctx context.Context, id string) error
+	// List returns the ids of the stored records in lexical order.
+	List(ctx context.Context) ([]string, error)
+}
+
+// Save stores the conversation in store.
+func (c *Conversation) Save(ctx context.Context, store ConversationStore) error {
+	return store.Save(ctx, c.Record())
+}
+
+// LoadConversation loads a conversation from store. opts is used as in RestoreConversation.
+func LoadConversation(ctx context.Context, store ConversationStore, id string, opts *ConversationOptions) (*Conversation, error) {
+	record, err := store.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return RestoreConversation(record, opts), nil
+}
+
+// MemoryConversationStore keeps conversation records in memory. It is safe for concurrent use.
+type MemoryConversationStore struct {
+	mu      sync.RWMutex
+	records map[string][]byte
+}
+
+// NewMemoryConversationStore creates an empty in-memory store.
+func NewMemoryConversationStore() *MemoryConversationStore {
+	return &MemoryConversationStore{records: map[string][]byte{}}
+}
+
+// Save stores a copy of the record.
+func (s *MemoryConversationStore) Save(ctx context.Context, record ConversationRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// Records are kept encoded so later changes to the caller's record do not leak into the store.
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.ID] = data
+	return nil
+}
+
+// Load returns a copy of the record with the given id.
+func (s *MemoryConversationStore) Load(ctx context.Context, id string) (ConversationRecord, error) {
+	var record ConversationRecord
+	if err := ctx.Err(); err != nil {
+		return record, err
+	}
+
+	s.mu.RLock()
+	data, ok := s.records[id]
+	s.mu.RUnlock()
+	if !ok {
+		return record, fmt.Errorf("%w: %s", ErrConversationNotFound, id)
+	}
+	err := json.Unmarshal(data, &record)
+	return record, err
+}
+
+// Delete removes the record with the given id.
+func (s *MemoryConversationStore) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, id)
+	return nil
+}
+
+// List returns the ids of the stored records in lexical order.
+func (s *MemoryConversationStore) List(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := make([]string, 0, len(s.records))
+	for id := range s.records {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// FileConversationStore keeps every conversation record in its own JSON file, named after the conversation id, in
+// a directory.
+type FileConversationStore struct {
+	dir string
+}
+
+// NewFileConversationStore creates a store in dir, creating the directory if needed.
+func NewFileConversationStore(dir string) (*FileConversationStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating conversation store: %w", err)
+	}
+	return &FileConversationStore{dir: dir}, nil
+}
+
+// path returns the file of a conversation, rejecting ids that would escape the store directory.
+func (s *FileConversationStore) path(id string) (string, error) {
+	if id == "" || strings.HasPrefix(id, ".") || strings.ContainsAny(id, `/\`) {
+		return "", fmt.Errorf("invalid conversation id %q", id)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+// Save writes the record to its file. The file is replaced atomically so readers never see a partial record.
+func (s *FileConversationStore) Save(ctx context.Context, record ConversationRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := s.path(record.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".tmp-"+record.ID+"-*")
+	if err != nil {
+		return fmt.Errorf("error saving conversation: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error saving conversation: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error saving conversation: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error saving conversation: %w", err)
+	}
+	return nil
+}
+
+// Load reads the record with the given id.
+func (s *FileConversationStore) Load(ctx context.Context, id string) (ConversationRecord, error) {
+	var record ConversationRecord
+	if err := ctx.Err(); err != nil {
+		return record, err
+	}
+	path, err := s.path(id)
+	if err != nil {
+		return record, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return record, fmt.Errorf("%w: %s", ErrConversationNotFound, id)
+	} else if err != nil {
+		return record, fmt.Errorf("error loading conversation: %w", err)
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, fmt.Errorf("error decoding conversation %s: %w", id, err)
+	}
+	return record, nil
+}
+
+// Delete removes the file of the record with the given id.
+func (s *FileConversationStore) Delete(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error deleting conversation: %w", err)
+	}
+	return nil
+}
+
+// List returns the ids of the stored records in lexical order.
+func (s *FileConversationStore) List(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("error listing conversations: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(name, ".json"))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
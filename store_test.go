@@ -0,0 +1,78 @@
+package mistral
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testConversationStore(t *testing.T, store ConversationStore) {
+	ctx := context.Background()
+
+	_, err := LoadConversation(ctx, store, "conv-test", nil)
+	assert.True(t, errors.Is(err, ErrConversationNotFound))
+
+	c := newExportConversation()
+	assert.NoError(t, c.Save(ctx, store))
+	assert.NoError(t, store.Save(ctx, ConversationRecord{ID: "conv-other"}))
+
+	ids, err := store.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"conv-other", "conv-test"}, ids)
+
+	restored, err := LoadConversation(ctx, store, "conv-test", &ConversationOptions{TokenCounter: countMessages})
+	assert.NoError(t, err)
+	assert.Equal(t, c.ID(), restored.ID())
+	assert.Equal(t, c.Model(), restored.Model())
+	assert.Equal(t, c.Usage(), restored.Usage())
+	assert.Equal(t, historyContents(c.History()), historyContents(restored.History()))
+
+	// Saved records are copies.
+	c.Append(UserMessage("later"))
+	restored, err = LoadConversation(ctx, store, "conv-test", nil)
+	assert.NoError(t, err)
+	assert.Len(t, restored.History(), 5)
+
+	assert.NoError(t, store.Delete(ctx, "conv-test"))
+	assert.NoError(t, store.Delete(ctx, "conv-test"))
+	ids, err = store.List(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"conv-other"}, ids)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, store.Save(cancelled, ConversationRecord{ID: "conv-cancelled"}), context.Canceled)
+}
+
+func TestMemoryConversationStore(t *testing.T) {
+	testConversationStore(t, NewMemoryConversationStore())
+}
+
+func TestFileConversationStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "conversations")
+	store, err := NewFileConversationStore(dir)
+	assert.NoError(t, err)
+	testConversationStore(t, store)
+
+	// Only the remaining record is left behind, without temporary files.
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "conv-other.json", entries[0].Name())
+	}
+}
+
+func TestFileConversationStoreInvalidID(t *testing.T) {
+	store, err := NewFileConversationStore(t.TempDir())
+	assert.NoError(t, err)
+
+	for _, id := range []string{"", "..", ".hidden", "../escape", `a\b`} {
+		assert.ErrorContains(t, store.Save(context.Background(), ConversationRecord{ID: id}), "invalid conversation id", id)
+		_, err := store.Load(context.Background(), id)
+		assert.ErrorContains(t, err, "invalid conversation id", id)
+	}
+}
@@ -0,0 +1,362 @@
+package mistral
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// ChatStreamReader iterates over the responses of a streamed chat completion.
+//
+//	stream, err := client.OpenChatStream(ctx, model, messages, params)
+//	if err != nil {
+//		return err
+//	}
+//	defer stream.Close()
+//	for stream.Next() {
+//		fmt.Print(stream.Current().Choices[0].Delta.Content)
+//	}
+//	return stream.Err()
+//
+// Every response read with Next is also added to an accumulator, so Accumulate returns the complete response
+// regardless of how much of the stream has already been consumed.
+type ChatStreamReader struct {
+	body        io.ReadCloser
+	decoder     *sseDecoder
+	current     ChatCompletionStreamResponse
+	completed   []ToolCall
+	accumulator ChatStreamAccumulator
+	err         error
+	done        bool
+	closed      atomic.Bool
+	closeOnce   sync.Once
+	closeErr    error
+}
+
+func newChatStreamReader(body io.ReadCloser) *ChatStreamReader {
+	return &ChatStreamReader{
+		body:    body,
+		decoder: newSSEDecoder(body),
+	}
+}
+
+// Next advances to the next streamed response, returning false once the stream has ended, failed or been closed.
+func (r *ChatStreamReader) Next() bool {
+	if r.done {
+		return false
+	}
+
+	r.completed = nil
+	for {
+		event, err := r.decoder.Next()
+		if errors.Is(err, io.EOF) {
+			r.finish(fmt.Errorf("error reading stream response: %w", io.ErrUnexpectedEOF))
+			return false
+		} else if err != nil {
+			r.finish(fmt.Errorf("error reading stream response: %w", err))
+			return false
+		}
+
+		data := bytes.TrimSpace(event.Data)
+		if len(data) == 0 {
+			continue
+		}
+
+		// Check for the special "[DONE]" message.
+		if bytes.Equal(data, []byte("[DONE]")) {
+			r.completed = r.accumulator.finishToolCalls()
+			r.finish(nil)
+			return false
+		}
+
+		if err := sseStreamError(event); err != nil {
+			r.finish(err)
+			return false
+		}
+
+		var streamResponse ChatCompletionStreamResponse
+		if err := json.Unmarshal(data, &streamResponse); err != nil {
+			r.finish(fmt.Errorf("error decoding stream response: %w", err))
+			return false
+		}
+
+		r.current = streamResponse
+		r.completed = r.accumulator.add(streamResponse)
+		return true
+	}
+}
+
+// finish ends the stream, recording err unless the stream was closed by the caller.
+func (r *ChatStreamReader) finish(err error) {
+	r.done = true
+	if err != nil && r.err == nil && !r.closed.Load() {
+		r.err = err
+	}
+	r.Close()
+}
+
+// Current returns the response read by the last call to Next.
+func (r *ChatStreamReader) Current() ChatCompletionStreamResponse {
+	return r.current
+}
+
+// CompletedToolCalls returns the tool calls completed by the response read by the last call to Next, so they can
+// be executed before the rest of the stream arrives. Once Next returns false at the end of the stream it returns the
+// calls that were still incomplete when the stream ended. Every call is returned exactly once.
+func (r *ChatStreamReader) CompletedToolCalls() []ToolCall {
+	return r.completed
+}
+
+// Err returns the error that ended the stream, or nil if it ended normally or was closed.
+func (r *ChatStreamReader) Err() error {
+	return r.err
+}
+
+// Close releases the underlying connection. It is safe to call Close at any time and more than once; once closed
+// Next returns false.
+func (r *ChatStreamReader) Close() error {
+	r.closed.Store(true)
+	r.closeOnce.Do(func() {
+		r.closeErr = r.body.Close()
+	})
+	return r.closeErr
+}
+
+// Accumulate reads the rest of the stream and returns the complete response built from every streamed delta.
+// The stream is closed when Accumulate returns.
+func (r *ChatStreamReader) Accumulate() (*ChatCompletionResponse, error) {
+	defer r.Close()
+	for r.Next() {
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.accumulator.Response(), nil
+}
+
+// streamToChannel forwards the responses of a stream to a channel until the stream ends or the context is done.
+// A stream failure is delivered as a final response with Error set. The stream is closed once forwarding stops.
+func streamToChannel(ctx context.Context, stream *ChatStreamReader) <-chan ChatCompletionStreamResponse {
+	responseChannel := make(chan ChatCompletionStreamResponse)
+
+	go func() {
+		defer close(responseChannel)
+		defer stream.Close()
+
+		// send delivers a response to the consumer unless the context is done first.
+		send := func(res ChatCompletionStreamResponse) bool {
+			select {
+			case responseChannel <- res:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for stream.Next() {
+			if !send(stream.Current()) {
+				return
+			}
+		}
+		if err := stream.Err(); err != nil {
+			send(ChatCompletionStreamResponse{Error: err})
+		}
+	}()
+
+	return responseChannel
+}
+
+// ChatStreamAccumulator merges streamed responses into a complete ChatCompletionResponse, concatenating content,
+// merging tool calls and keeping the last finish reason and usage block of every choice.
+// The zero value is ready to use.
+type ChatStreamAccumulator struct {
+	response ChatCompletionResponse
+	choices  map[int]*ChatCompletionResponseChoice
+	tools    map[int]*ToolCallAccumulator
+}
+
+// Add merges a streamed response into the accumulated response.
+func (a *ChatStreamAccumulator) Add(chunk ChatCompletionStreamResponse) {
+	a.add(chunk)
+}
+
+// add merges a streamed response and returns the tool calls it completed.
+func (a *ChatStreamAccumulator) add(chunk ChatCompletionStreamResponse) []ToolCall {
+	if a.choices == nil {
+		a.choices = map[int]*ChatCompletionResponseChoice{}
+		a.tools = map[int]*ToolCallAccumulator{}
+	}
+	if a.response.ID == "" {
+		a.response.ID = chunk.ID
+	}
+	if chunk.Model != "" {
+		a.response.Model = chunk.Model
+	}
+	if a.response.Created == 0 {
+		a.response.Created = chunk.Created
+	}
+	if chunk.Usage != (UsageInfo{}) {
+		a.response.Usage = chunk.Usage
+	}
+
+	var completed []ToolCall
+	for _, delta := range chunk.Choices {
+		choice, ok := a.choices[delta.Index]
+		if !ok {
+			choice = &ChatCompletionResponseChoice{Index: delta.Index}
+			a.choices[delta.Index] = choice
+			a.tools[delta.Index] = &ToolCallAccumulator{}
+		}
+		tools := a.tools[delta.Index]
+		if delta.Delta.Role != "" {
+			choice.Message.Role = delta.Delta.Role
+		}
+		choice.Message.Content += delta.Delta.Content
+		completed = append(completed, tools.Add(delta.Delta.ToolCalls)...)
+		choice.Message.ToolCalls = tools.ToolCalls()
+		if delta.FinishReason != "" {
+			choice.FinishReason = delta.FinishReason
+			completed = append(completed, tools.Finish()...)
+		}
+	}
+	return completed
+}
+
+// finishToolCalls completes the tool calls of every choice, in choice order.
+func (a *ChatStreamAccumulator) finishToolCalls() []ToolCall {
+	indices := make([]int, 0, len(a.tools))
+	for index := range a.tools {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+
+	var completed []ToolCall
+	for _, index := range indices {
+		completed = append(completed, a.tools[index].Finish()...)
+	}
+	return completed
+}
+
+// Response returns the response accumulated so far.
+func (a *ChatStreamAccumulator) Response() *ChatCompletionResponse {
+	response := a.response
+	response.Object = "chat.completion"
+	response.Choices = make([]ChatCompletionResponseChoice, 0, len(a.choices))
+	for _, choice := range a.choices {
+		c := *choice
+		if c.Message.Role == "" {
+			c.Message.Role = RoleAssistant
+		}
+		response.Choices = append(response.Choices, c)
+	}
+	sort.Slice(response.Choices, func(i, j int) bool {
+		return response.Choices[i].Index < response.Choices[j].Index
+	})
+	return &response
+}
+
+// ToolCallAccumulator merges streamed tool call deltas into complete tool calls. Deltas are matched to calls by id,
+// or by index for deltas without an id, and their argument fragments are concatenated.
+//
+// A call is complete once its arguments form a complete JSON object, once a later call starts, or when Finish is
+// called because the choice finished. Add and Finish return each call once, as soon as it is complete, so a tool can
+// be executed while the model is still generating the remaining calls. The zero value is ready to use.
+type ToolCallAccumulator struct {
+	calls []*streamedToolCall
+}
+
+type streamedToolCall struct {
+	call      ToolCall
+	arguments PartialJSONParser
+	complete  bool
+}
+
+// Add merges the tool call deltas of a streamed response and returns the calls they completed.
+func (a *ToolCallAccumulator) Add(deltas []ToolCall) []ToolCall {
+	var completed []ToolCall
+	for _, delta := range deltas {
+		target := a.find(delta)
+		if target == nil {
+			// A new call ends every call before it.
+			completed = append(completed, a.Finish()...)
+			target = &streamedToolCall{call: ToolCall{Id: delta.Id, Index: delta.Index}}
+			a.calls = append(a.calls, target)
+		}
+
+		call := &target.call
+		if call.Id == "" {
+			call.Id = delta.Id
+		}
+		if delta.Type != "" {
+			call.Type = delta.Type
+		}
+		if delta.Function.Name != "" {
+			call.Function.Name = delta.Function.Name
+		}
+		call.Function.Arguments += delta.Function.Arguments
+		target.arguments.Write(delta.Function.Arguments)
+
+		if !target.complete && call.Function.Name != "" && target.arguments.Complete() {
+			target.complete = true
+			completed = append(completed, target.toolCall())
+		}
+	}
+	return completed
+}
+
+// Finish marks every call as complete and returns the calls that were not complete yet.
+func (a *ToolCallAccumulator) Finish() []ToolCall {
+	var completed []ToolCall
+	for _, c := range a.calls {
+		if !c.complete {
+			c.complete = true
+			completed = append(completed, c.toolCall())
+		}
+	}
+	return completed
+}
+
+// ToolCalls returns every call merged so far, including incomplete ones, in the order they started.
+func (a *ToolCallAccumulator) ToolCalls() []ToolCall {
+	if len(a.calls) == 0 {
+		return nil
+	}
+	calls := make([]ToolCall, len(a.calls))
+	for i, c := range a.calls {
+		calls[i] = c.toolCall()
+	}
+	return calls
+}
+
+// find returns the call a delta belongs to: the call with the same id, or else the most recent call with the same
+// index that has no id of its own yet.
+func (a *ToolCallAccumulator) find(delta ToolCall) *streamedToolCall {
+	if delta.Id != "" {
+		for _, c := range a.calls {
+			if c.call.Id == delta.Id {
+				return c
+			}
+		}
+	}
+	for i := len(a.calls) - 1; i >= 0; i-- {
+		c := a.calls[i]
+		if c.call.Index == delta.Index && (delta.Id == "" || c.call.Id == "") {
+			return c
+		}
+	}
+	return nil
+}
+
+func (c *streamedToolCall) toolCall() ToolCall {
+	call := c.call
+	if call.Type == "" {
+		call.Type = ToolTypeFunction
+	}
+	return call
+}
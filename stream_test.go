@@ -0,0 +1,206 @@
+package mistral
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChatStreamReaderAccumulate(t *testing.T) {
+	srv := newScriptedServer(t, testStream{
+		`{"id":"abc","model":"mistral-small","created":1,"choices":[{"index":0,"delta":{"role":"assistant","content":""}}]}`,
+		`{"id":"abc","model":"mistral-small","choices":[{"index":0,"delta":{"content":"Hello"}}]}`,
+		`{"id":"abc","model":"mistral-small","choices":[{"index":0,"delta":{"content":" world","tool_calls":[{"id":"call1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":"}}]}}]}`,
+		`{"id":"abc","model":"mistral-small","choices":[{"index":0,"delta":{"tool_calls":[{"function":{"arguments":" \"Dallas\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":5,"total_tokens":12,"completion_tokens":7}}`,
+	})
+
+	client := srv.Client()
+	stream, err := client.OpenChatStream(context.Background(), ModelMistralSmallLatest, []ChatMessage{{Role: RoleUser, Content: "hi"}}, nil)
+	assert.NoError(t, err)
+
+	assert.True(t, stream.Next())
+	assert.Equal(t, RoleAssistant, stream.Current().Choices[0].Delta.Role)
+
+	res, err := stream.Accumulate()
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", res.ID)
+	assert.Equal(t, "mistral-small", res.Model)
+	assert.Equal(t, 1, res.Created)
+	assert.Len(t, res.Choices, 1)
+	assert.Equal(t, RoleAssistant, res.Choices[0].Message.Role)
+	assert.Equal(t, "Hello world", res.Choices[0].Message.Content)
+	assert.Equal(t, FinishReasonToolCalls, res.Choices[0].FinishReason)
+	assert.Equal(t, []ToolCall{{
+		Id:       "call1",
+		Type:     ToolTypeFunction,
+		Function: FunctionCall{Name: "get_weather", Arguments: `{"city": "Dallas"}`},
+	}}, res.Choices[0].Message.ToolCalls)
+	assert.Equal(t, UsageInfo{PromptTokens: 5, TotalTokens: 12, CompletionTokens: 7}, res.Usage)
+	assert.False(t, stream.Next())
+	assert.NoError(t, stream.Err())
+}
+
+func TestChatStreamReaderDecodeError(t *testing.T) {
+	events := testStream{
+		`{"id":"abc","choices":[{"index":0,"delta":{"content":"a"}}]}`,
+		`{"id":`,
+	}
+	srv := newScriptedServer(t, events, events)
+
+	client := srv.Client()
+	stream, err := client.OpenChatStream(context.Background(), ModelMistralSmallLatest, nil, nil)
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	assert.True(t, stream.Next())
+	assert.False(t, stream.Next())
+	assert.ErrorContains(t, stream.Err(), "error decoding stream response")
+
+	resChan, err := client.ChatStream(ModelMistralSmallLatest, nil, nil)
+	assert.NoError(t, err)
+	var last ChatCompletionStreamResponse
+	for res := range resChan {
+		last = res
+	}
+	assert.Error(t, last.Error)
+}
+
+type blockingBody struct {
+	closed chan struct{}
+}
+
+func (b *blockingBody) Read(p []byte) (int, error) {
+	<-b.closed
+	return 0, io.ErrClosedPipe
+}
+
+func (b *blockingBody) Close() error {
+	close(b.closed)
+	return nil
+}
+
+func TestChatStreamReaderCloseEarly(t *testing.T) {
+	body := &blockingBody{closed: make(chan struct{})}
+	stream := newChatStreamReader(body)
+
+	done := make(chan bool)
+	go func() {
+		done <- stream.Next()
+	}()
+
+	assert.NoError(t, stream.Close())
+	assert.NoError(t, stream.Close())
+	select {
+	case next := <-done:
+		assert.False(t, next)
+	case <-time.After(time.Second):
+		t.Fatal("Next did not return after Close")
+	}
+	assert.NoError(t, stream.Err())
+}
+
+func TestChatStreamAccumulatorMultipleChoices(t *testing.T) {
+	var acc ChatStreamAccumulator
+	acc.Add(ChatCompletionStreamResponse{ID: "x", Choices: []ChatCompletionResponseChoiceStream{
+		{Index: 1, Delta: DeltaMessage{Content: "b"}},
+		{Index: 0, Delta: DeltaMessage{Content: "a"}},
+	}})
+	acc.Add(ChatCompletionStreamResponse{ID: "x", Choices: []ChatCompletionResponseChoiceStream{
+		{Index: 0, Delta: DeltaMessage{Content: "a"}, FinishReason: FinishReasonStop},
+		{Index: 1, Delta: DeltaMessage{Content: "b"}, FinishReason: FinishReasonLength},
+	}})
+
+	res := acc.Response()
+	assert.Len(t, res.Choices, 2)
+	assert.Equal(t, "aa", res.Choices[0].Message.Content)
+	assert.Equal(t, FinishReasonStop, res.Choices[0].FinishReason)
+	assert.Equal(t, "bb", res.Choices[1].Message.Content)
+	assert.Equal(t, FinishReasonLength, res.Choices[1].FinishReason)
+}
+
+func TestToolCallAccumulator(t *testing.T) {
+	var a ToolCallAccumulator
+
+	completed := a.Add([]ToolCall{{Id: "call1", Index: 0, Type: ToolTypeFunction, Function: FunctionCall{Name: "get_weather", Arguments: `{"city": "Da`}}})
+	assert.Empty(t, completed)
+	assert.Equal(t, []ToolCall{{
+		Id:       "call1",
+		Type:     ToolTypeFunction,
+		Function: FunctionCall{Name: "get_weather", Arguments: `{"city": "Da`},
+	}}, a.ToolCalls())
+
+	// The call is complete as soon as its arguments close.
+	completed = a.Add([]ToolCall{{Index: 0, Function: FunctionCall{Arguments: `llas"}`}}})
+	assert.Equal(t, []ToolCall{{
+		Id:       "call1",
+		Type:     ToolTypeFunction,
+		Function: FunctionCall{Name: "get_weather", Arguments: `{"city": "Dallas"}`},
+	}}, completed)
+
+	// A call with malformed arguments is completed by the next call.
+	assert.Empty(t, a.Add([]ToolCall{{Id: "call2", Index: 1, Function: FunctionCall{Name: "get_time", Arguments: `{"tz": `}}}))
+	completed = a.Add([]ToolCall{{Id: "call3", Index: 2, Function: FunctionCall{Name: "get_date", Arguments: `{`}}})
+	if assert.Len(t, completed, 1) {
+		assert.Equal(t, "call2", completed[0].Id)
+		assert.Equal(t, ToolTypeFunction, completed[0].Type)
+	}
+
+	completed = a.Finish()
+	if assert.Len(t, completed, 1) {
+		assert.Equal(t, "call3", completed[0].Id)
+	}
+	assert.Empty(t, a.Finish())
+	assert.Len(t, a.ToolCalls(), 3)
+}
+
+func TestToolCallAccumulatorMatchesByIndex(t *testing.T) {
+	var a ToolCallAccumulator
+
+	a.Add([]ToolCall{
+		{Index: 0, Function: FunctionCall{Name: "first"}},
+		{Index: 1, Id: "b", Function: FunctionCall{Name: "second", Arguments: `{"n":`}},
+	})
+	// The id of the first call arrives after its name and the fragments of both calls are interleaved.
+	a.Add([]ToolCall{
+		{Index: 0, Id: "a", Function: FunctionCall{Arguments: `{"n":`}},
+		{Index: 1, Function: FunctionCall{Arguments: `2}`}},
+		{Index: 0, Function: FunctionCall{Arguments: `1}`}},
+	})
+
+	assert.Equal(t, []ToolCall{
+		{Id: "a", Index: 0, Type: ToolTypeFunction, Function: FunctionCall{Name: "first", Arguments: `{"n":1}`}},
+		{Id: "b", Index: 1, Type: ToolTypeFunction, Function: FunctionCall{Name: "second", Arguments: `{"n":2}`}},
+	}, a.ToolCalls())
+}
+
+func TestChatStreamReaderCompletedToolCalls(t *testing.T) {
+	srv := newScriptedServer(t, testStream{
+		`{"id":"abc","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"id":"call1","index":0,"function":{"name":"get_weather","arguments":"{\"city\":"}}]}}]}`,
+		`{"id":"abc","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":" \"Dallas\"}"}},{"id":"call2","index":1,"function":{"name":"get_time","arguments":""}}]}}]}`,
+		`{"id":"abc","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"function":{"arguments":"{\"tz\": \"CST\""}}]},"finish_reason":"tool_calls"}]}`,
+	})
+
+	client := srv.Client()
+	stream, err := client.OpenChatStream(context.Background(), ModelMistralSmallLatest, nil, nil)
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	var completed [][]string
+	for stream.Next() {
+		var ids []string
+		for _, call := range stream.CompletedToolCalls() {
+			ids = append(ids, call.Id)
+		}
+		completed = append(completed, ids)
+	}
+	assert.NoError(t, stream.Err())
+	assert.Equal(t, [][]string{nil, {"call1"}, {"call2"}}, completed)
+	assert.Empty(t, stream.CompletedToolCalls())
+
+	res, err := stream.Accumulate()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"tz": "CST"`, res.Choices[0].Message.ToolCalls[1].Function.Arguments)
+}
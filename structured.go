@@ -0,0 +1,125 @@
+package mistral
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const DefaultStructuredOutputAttempts = 3
+
+// ErrStructuredOutput is returned by ChatInto when the model does not produce a valid response within the allowed
+// number of attempts.
+var ErrStructuredOutput = errors.New("model did not produce a valid structured response")
+
+// StructuredOutputOptions configures ChatInto.
+type StructuredOutputOptions struct {
+	Name        string // The name of the schema. Defaults to the name of the Go type.
+	Description string // Describes the expected response to the model.
+	Strict      *bool  // Whether the API must enforce the schema strictly. Defaults to true; set with Ptr.
+	MaxAttempts int    // Total number of requests made before giving up. Defaults to DefaultStructuredOutputAttempts.
+}
+
+var schemaNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// ChatInto sends a chat request with a json_schema response format derived from T and decodes the response into T.
+// The response is validated against the schema; when it does not parse or validate, the error is sent back to the
+// model and the request retried, up to opts.MaxAttempts requests. A nil opts uses the default of every option. The
+// last response is returned along with the decoded value, or with an error wrapping ErrStructuredOutput if every
+// attempt failed.
+func ChatInto[T any](ctx context.Context, client *MistralClient, model string, messages []ChatMessage, params *ChatRequestParams, opts *StructuredOutputOptions) (*T, *ChatCompletionResponse, error) {
+	if params == nil {
+		params = &DefaultChatRequestParams
+	}
+	if opts == nil {
+		opts = &StructuredOutputOptions{}
+	}
+	strict := true
+	if opts.Strict != nil {
+		strict = *opts.Strict
+	}
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultStructuredOutputAttempts
+	}
+
+	schema, err := JSONSchemaFor[T]()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error generating response schema: %w", err)
+	}
+	name := opts.Name
+	if name == "" {
+		name = schemaNameInvalidChars.ReplaceAllString(reflect.TypeOf((*T)(nil)).Elem().Name(), "_")
+	}
+	if name == "" {
+		name = "response"
+	}
+
+	requestParams := *params
+	requestParams.ResponseFormat = ResponseFormatJsonSchema
+	requestParams.JSONSchema = &JSONSchemaFormat{
+		Name:        name,
+		Description: opts.Description,
+		Schema:      schema,
+		Strict:      strict,
+	}
+
+	conversation := append([]ChatMessage(nil), messages...)
+	var res *ChatCompletionResponse
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		res, err = client.ChatContext(ctx, model, conversation, &requestParams)
+		if err != nil {
+			return nil, res, err
+		}
+		if len(res.Choices) == 0 {
+			return nil, res, errors.New("response has no choices")
+		}
+
+		content := res.Choices[0].Message.Content
+		value, err := decodeStructuredOutput[T](schema, content)
+		if err == nil {
+			return value, res, nil
+		}
+		lastErr = err
+
+		conversation = append(conversation,
+			AssistantMessage(content),
+			UserMessage(fmt.Sprintf("Your previous response could not be used: %v. Respond again with only a JSON value matching the schema.", err)),
+		)
+	}
+
+	return nil, res, fmt.Errorf("%w after %d attempts: %v", ErrStructuredOutput, maxAttempts, lastErr)
+}
+
+// decodeStructuredOutput validates content against schema and decodes it into T. Markdown code fences around the
+// JSON are ignored.
+func decodeStructuredOutput[T any](schema any, content string) (*T, error) {
+	content = stripCodeFence(content)
+	if err := ValidateJSON(schema, []byte(content)); err != nil {
+		return nil, err
+	}
+
+	var value T
+	if err := json.Unmarshal([]byte(content), &value); err != nil {
+		return nil, fmt.Errorf("error decoding response: %w", err)
+	}
+	return &value, nil
+}
+
+// stripCodeFence removes a surrounding markdown code fence such as ```json ... ``` from s.
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") || !strings.HasSuffix(s, "```") || len(s) < 6 {
+		return s
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "```"), "```")
+	if i := strings.IndexByte(s, '\n'); i >= 0 && !strings.ContainsAny(s[:i], "{[\"") {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(s)
+}
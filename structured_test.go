@@ -0,0 +1,77 @@
+package mistral
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type codeSymbols struct {
+	Symbols []string `json:"symbols" description:"Code symbols found in the text"`
+}
+
+func TestChatInto(t *testing.T) {
+	srv := newScriptedServer(t,
+		answerResponse(`{"symbols": "ChatMessage"}`),
+		answerResponse("```json\n{\"symbols\": [\"ChatMessage\", \"ToolCall\"]}\n```"),
+	)
+	client := srv.Client()
+	value, res, err := ChatInto[codeSymbols](context.Background(), client, ModelMistralSmallLatest, []ChatMessage{UserMessage("Extract the code symbols")}, nil, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+	assert.Equal(t, &codeSymbols{Symbols: []string{"ChatMessage", "ToolCall"}}, value)
+
+	requests := srv.Requests()
+	assert.Len(t, requests, 2)
+	format := requests[0].JSON()["response_format"].(map[string]interface{})
+	assert.Equal(t, "json_schema", format["type"])
+	jsonSchema := format["json_schema"].(map[string]interface{})
+	assert.Equal(t, "codeSymbols", jsonSchema["name"])
+	assert.Equal(t, true, jsonSchema["strict"])
+	assert.Equal(t, "object", jsonSchema["schema"].(map[string]interface{})["type"])
+
+	retryMessages := requests[1].Messages()
+	assert.Len(t, retryMessages, 3)
+	assert.Contains(t, retryMessages[2].Content, "$.symbols: expected array but got string")
+}
+
+func TestChatIntoGivesUp(t *testing.T) {
+	srv := newScriptedServer(t, answerResponse("not json"), answerResponse("still not json"))
+	client := srv.Client()
+
+	value, res, err := ChatInto[codeSymbols](context.Background(), client, ModelMistralSmallLatest, []ChatMessage{UserMessage("hi")}, nil, &StructuredOutputOptions{Name: "symbols", MaxAttempts: 2})
+	assert.ErrorIs(t, err, ErrStructuredOutput)
+	assert.Nil(t, value)
+	assert.Equal(t, "still not json", res.Choices[0].Message.Content)
+	assert.Len(t, srv.Requests(), 2)
+}
+
+func TestChatIntoStrict(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   *StructuredOutputOptions
+		strict bool
+	}{
+		{"nil options", nil, true},
+		{"options without strict", &StructuredOutputOptions{MaxAttempts: 2}, true},
+		{"strict disabled", &StructuredOutputOptions{Strict: Ptr(false)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newScriptedServer(t, answerResponse(`{"symbols": []}`))
+			_, _, err := ChatInto[codeSymbols](context.Background(), srv.Client(), ModelMistralSmallLatest, []ChatMessage{UserMessage("hi")}, nil, tt.opts)
+			assert.NoError(t, err)
+
+			format := srv.Requests()[0].JSON()["response_format"].(map[string]interface{})
+			assert.Equal(t, tt.strict, format["json_schema"].(map[string]interface{})["strict"])
+		})
+	}
+}
+
+func TestStripCodeFence(t *testing.T) {
+	assert.Equal(t, `{"a":1}`, stripCodeFence("```json\n{\"a\":1}\n```"))
+	assert.Equal(t, `{"a":1}`, stripCodeFence("```\n{\"a\":1}\n```"))
+	assert.Equal(t, `{"a":1}`, stripCodeFence(`{"a":1}`))
+	assert.Equal(t, `{"a":1}`, stripCodeFence("```{\"a\":1}```"))
+}
@@ -0,0 +1,112 @@
+package mistral
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// testServer is the fake API server of the tests of this package, a minimal version of mistraltest.Server, which
+// cannot be used here since it imports this package. It records every request before passing it to its handler.
+type testServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []testRequest
+}
+
+// testRequest is a request received by a testServer.
+type testRequest struct {
+	Path string
+	Body []byte
+}
+
+// Decode decodes the JSON body of the request into v.
+func (r testRequest) Decode(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// JSON returns the JSON body of the request as a map.
+func (r testRequest) JSON() map[string]any {
+	var body map[string]any
+	r.Decode(&body)
+	return body
+}
+
+// Messages returns the messages of a chat completion request.
+func (r testRequest) Messages() []ChatMessage {
+	var body struct {
+		Messages []ChatMessage `json:"messages"`
+	}
+	r.Decode(&body)
+	return body.Messages
+}
+
+// testStream is a scripted response streamed as server-sent events, one per chunk, followed by [DONE].
+type testStream []string
+
+// newTestServer starts a server answering every request with handler. The server is closed when the test ends.
+func newTestServer(t *testing.T, handler http.HandlerFunc) *testServer {
+	t.Helper()
+	s := &testServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("error reading request body: %v", err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		s.mu.Lock()
+		s.requests = append(s.requests, testRequest{Path: r.URL.Path, Body: body})
+		s.mu.Unlock()
+
+		handler(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// newScriptedServer starts a server answering requests with responses in order and with an error once they are used
+// up. A testStream is sent as an event stream and any other response is encoded as JSON.
+func newScriptedServer(t *testing.T, responses ...any) *testServer {
+	t.Helper()
+	var mu sync.Mutex
+	next := 0
+	return newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		i := next
+		next++
+		mu.Unlock()
+
+		if i >= len(responses) {
+			http.Error(w, `{"message":"no more scripted responses"}`, http.StatusInternalServerError)
+			return
+		}
+		stream, ok := responses[i].(testStream)
+		if !ok {
+			json.NewEncoder(w).Encode(responses[i])
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range stream {
+			w.Write([]byte("data: " + chunk + "\n\n"))
+		}
+		w.Write([]byte("data: [DONE]\n\n"))
+	})
+}
+
+// Client returns a client for the server.
+func (s *testServer) Client(opts ...ClientOption) *MistralClient {
+	return NewMistralClientWithOptions("test", append([]ClientOption{WithBaseURL(s.URL)}, opts...)...)
+}
+
+// Requests returns the requests received so far.
+func (s *testServer) Requests() []testRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]testRequest(nil), s.requests...)
+}
@@ -0,0 +1,68 @@
+package tokenizer
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/gage-technologies/mistral-go"
+)
+
+// ErrUnknownModel is returned by LoadModel for models without a registered tokenizer.
+var ErrUnknownModel = errors.New("no tokenizer registered for model")
+
+// ModelTokenizer names the tokenizer file and instruct format used by a model.
+type ModelTokenizer struct {
+	File    string  // The name of the tokenizer file, as published with the model weights.
+	Version Version // The instruct format of the model.
+}
+
+var (
+	modelTokenizersMu sync.RWMutex
+	modelTokenizers   = map[string]ModelTokenizer{
+		mistral.ModelOpenMistral7b:     {File: "tokenizer.model.v1", Version: V1},
+		mistral.ModelOpenMixtral8x7b:   {File: "tokenizer.model.v1", Version: V1},
+		mistral.ModelMistralTiny:       {File: "tokenizer.model.v1", Version: V1},
+		mistral.ModelMistralSmall2312:  {File: "tokenizer.model.v1", Version: V1},
+		mistral.ModelMistralMedium2312: {File: "tokenizer.model.v1", Version: V1},
+		mistral.ModelMistralSmall2402:  {File: "tokenizer.model.v2", Version: V2},
+		mistral.ModelMistralLarge2402:  {File: "tokenizer.model.v2", Version: V2},
+		mistral.ModelOpenMixtral8x22b:  {File: "tokenizer.model.v3", Version: V3},
+
+		// The latest aliases move to new model versions over time; register an override if they change.
+		mistral.ModelMistralLargeLatest:  {File: "tokenizer.model.v7", Version: V7},
+		mistral.ModelMistralMediumLatest: {File: "tekken.json", Version: V7},
+		mistral.ModelMistralSmallLatest:  {File: "tekken.json", Version: V7},
+		mistral.ModelCodestralLatest:     {File: "tekken.json", Version: V7},
+	}
+)
+
+// RegisterModel sets the tokenizer used by a model, adding a model or overriding the built-in mapping.
+func RegisterModel(model string, tokenizer ModelTokenizer) {
+	modelTokenizersMu.Lock()
+	defer modelTokenizersMu.Unlock()
+	modelTokenizers[model] = tokenizer
+}
+
+// ModelTokenizerFor returns the tokenizer used by a model.
+func ModelTokenizerFor(model string) (ModelTokenizer, bool) {
+	modelTokenizersMu.RLock()
+	defer modelTokenizersMu.RUnlock()
+	tokenizer, ok := modelTokenizers[model]
+	return tokenizer, ok
+}
+
+// LoadModel loads the tokenizer of a model from dir, which holds the tokenizer files under their published names,
+// and returns its chat template.
+func LoadModel(dir string, model string) (*ChatTemplate, error) {
+	spec, ok := ModelTokenizerFor(model)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownModel, model)
+	}
+	tok, err := Load(filepath.Join(dir, spec.File))
+	if err != nil {
+		return nil, err
+	}
+	return NewChatTemplate(tok, spec.Version)
+}
@@ -0,0 +1,322 @@
+package tokenizer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// PieceType is the type of a SentencePiece vocabulary entry.
+type PieceType int
+
+const (
+	PieceNormal      PieceType = 1
+	PieceUnknown     PieceType = 2
+	PieceControl     PieceType = 3
+	PieceUserDefined PieceType = 4
+	PieceUnused      PieceType = 5
+	PieceByte        PieceType = 6
+)
+
+// Piece is an entry of a SentencePiece vocabulary. Its id is its position in the vocabulary.
+type Piece struct {
+	Text  string
+	Score float32
+	Type  PieceType
+}
+
+// sentencePieceSpace replaces spaces in SentencePiece pieces.
+const sentencePieceSpace = "▁"
+
+// SentencePiece is a SentencePiece BPE tokenizer, the format of Mistral's tokenizer.model.v1 to tokenizer.model.v7
+// files. Only the identity normalization used by Mistral's models is supported.
+type SentencePiece struct {
+	pieces     []Piece
+	ids        map[string]int // Pieces that ordinary text can be encoded to.
+	all        map[string]int // Every piece, for TokenID.
+	bytes      [256]int       // The byte fallback pieces, -1 when missing.
+	unknownID  int            // The unknown piece, or 0 if the model has none.
+	dummy      bool           // Whether a space is prepended to the text before encoding.
+	collapseWS bool           // Whether leading, trailing and repeated spaces are removed before encoding.
+}
+
+// LoadSentencePiece loads a SentencePiece model file.
+func LoadSentencePiece(path string) (*SentencePiece, error) {
+	data, err := readTokenizerFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSentencePiece(data)
+}
+
+// ParseSentencePiece parses a serialized SentencePiece model. Only BPE models are supported.
+func ParseSentencePiece(data []byte) (*SentencePiece, error) {
+	sp := &SentencePiece{
+		ids:        map[string]int{},
+		all:        map[string]int{},
+		dummy:      true,
+		collapseWS: true,
+	}
+	for i := range sp.bytes {
+		sp.bytes[i] = -1
+	}
+	modelType := uint64(1) // Unigram is the protobuf default.
+
+	err := walkProto(data, func(field int, wireType int, value uint64, payload []byte) error {
+		switch field {
+		case 1: // pieces
+			piece, err := parsePiece(payload)
+			if err != nil {
+				return err
+			}
+			sp.pieces = append(sp.pieces, piece)
+		case 2: // trainer_spec
+			return walkProto(payload, func(field int, wireType int, value uint64, payload []byte) error {
+				if field == 3 { // model_type
+					modelType = value
+				}
+				return nil
+			})
+		case 3: // normalizer_spec
+			return walkProto(payload, func(field int, wireType int, value uint64, payload []byte) error {
+				switch field {
+				case 1: // name
+					if name := string(payload); name != "identity" && name != "" {
+						return fmt.Errorf("unsupported normalization %q", name)
+					}
+				case 3: // add_dummy_prefix
+					sp.dummy = value != 0
+				case 4: // remove_extra_whitespaces
+					sp.collapseWS = value != 0
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error parsing SentencePiece model: %w", err)
+	}
+	if modelType != 2 {
+		return nil, fmt.Errorf("error parsing SentencePiece model: unsupported model type %d, only BPE is supported", modelType)
+	}
+	if len(sp.pieces) == 0 {
+		return nil, errors.New("error parsing SentencePiece model: no pieces")
+	}
+
+	for id, piece := range sp.pieces {
+		if _, ok := sp.all[piece.Text]; !ok {
+			sp.all[piece.Text] = id
+		}
+		switch piece.Type {
+		case PieceNormal, PieceUserDefined:
+			if _, ok := sp.ids[piece.Text]; !ok {
+				sp.ids[piece.Text] = id
+			}
+		case PieceUnknown:
+			sp.unknownID = id
+		case PieceByte:
+			if b, ok := parseBytePiece(piece.Text); ok {
+				sp.bytes[b] = id
+			}
+		}
+	}
+	return sp, nil
+}
+
+func parsePiece(data []byte) (Piece, error) {
+	piece := Piece{Type: PieceNormal}
+	err := walkProto(data, func(field int, wireType int, value uint64, payload []byte) error {
+		switch field {
+		case 1:
+			piece.Text = string(payload)
+		case 2:
+			if wireType != 5 {
+				return fmt.Errorf("invalid piece score")
+			}
+			piece.Score = math.Float32frombits(uint32(value))
+		case 3:
+			piece.Type = PieceType(value)
+		}
+		return nil
+	})
+	return piece, err
+}
+
+// parseBytePiece parses the text of a byte fallback piece, such as "<0x0A>".
+func parseBytePiece(text string) (byte, bool) {
+	if len(text) != 6 || !strings.HasPrefix(text, "<0x") || !strings.HasSuffix(text, ">") {
+		return 0, false
+	}
+	b, err := strconv.ParseUint(text[3:5], 16, 8)
+	return byte(b), err == nil
+}
+
+// Pieces returns the vocabulary of the model.
+func (sp *SentencePiece) Pieces() []Piece {
+	return sp.pieces
+}
+
+// VocabSize returns the number of pieces in the vocabulary.
+func (sp *SentencePiece) VocabSize() int {
+	return len(sp.pieces)
+}
+
+// TokenID returns the id of a piece.
+func (sp *SentencePiece) TokenID(token string) (int, bool) {
+	id, ok := sp.all[token]
+	return id, ok
+}
+
+// Encode converts text to piece ids. Spaces are replaced with "▁" and pieces are merged by score; characters outside
+// the vocabulary are encoded as byte fallback pieces, or the unknown piece when the model has none.
+func (sp *SentencePiece) Encode(text string) []int {
+	text = sp.normalize(text)
+	if text == "" {
+		return nil
+	}
+
+	symbols := make([]string, 0, len(text))
+	for _, r := range text {
+		symbols = append(symbols, string(r))
+	}
+	symbols = bpeMerge(symbols, func(merged string) (float64, bool) {
+		id, ok := sp.ids[merged]
+		if !ok {
+			return 0, false
+		}
+		return -float64(sp.pieces[id].Score), true
+	})
+
+	ids := make([]int, 0, len(symbols))
+	for _, s := range symbols {
+		if id, ok := sp.ids[s]; ok {
+			ids = append(ids, id)
+			continue
+		}
+		ids = append(ids, sp.fallback(s)...)
+	}
+	return ids
+}
+
+func (sp *SentencePiece) normalize(text string) string {
+	if sp.collapseWS {
+		text = strings.Join(strings.FieldsFunc(text, func(r rune) bool { return r == ' ' }), " ")
+	}
+	if text == "" {
+		return ""
+	}
+	if sp.dummy {
+		text = " " + text
+	}
+	return strings.ReplaceAll(text, " ", sentencePieceSpace)
+}
+
+// fallback encodes a symbol that is not in the vocabulary.
+func (sp *SentencePiece) fallback(s string) []int {
+	ids := make([]int, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		id := sp.bytes[s[i]]
+		if id < 0 {
+			return []int{sp.unknownID}
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Decode converts piece ids back to text. Control pieces are skipped and the space added by Encode is removed.
+func (sp *SentencePiece) Decode(ids []int) string {
+	var b strings.Builder
+	var pending []byte // byte fallback pieces are combined before being decoded as UTF-8
+	flush := func() {
+		for len(pending) > 0 {
+			r, n := utf8.DecodeRune(pending)
+			b.WriteRune(r)
+			pending = pending[n:]
+		}
+	}
+
+	for _, id := range ids {
+		if id < 0 || id >= len(sp.pieces) {
+			continue
+		}
+		piece := sp.pieces[id]
+		switch piece.Type {
+		case PieceControl, PieceUnused:
+			continue
+		case PieceByte:
+			if c, ok := parseBytePiece(piece.Text); ok {
+				pending = append(pending, c)
+				continue
+			}
+		case PieceUnknown:
+			flush()
+			b.WriteString(" ⁇ ")
+			continue
+		}
+		flush()
+		b.WriteString(piece.Text)
+	}
+	flush()
+
+	text := strings.ReplaceAll(b.String(), sentencePieceSpace, " ")
+	if sp.dummy {
+		text = strings.TrimPrefix(text, " ")
+	}
+	return text
+}
+
+// walkProto calls fn for every field of a protobuf message. Varint and fixed-size values are passed as value and
+// length-delimited values as payload.
+func walkProto(data []byte, fn func(field int, wireType int, value uint64, payload []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("invalid field key")
+		}
+		data = data[n:]
+		field, wireType := int(key>>3), int(key&7)
+
+		var value uint64
+		var payload []byte
+		switch wireType {
+		case 0:
+			value, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errors.New("invalid varint")
+			}
+			data = data[n:]
+		case 1:
+			if len(data) < 8 {
+				return errors.New("truncated fixed64")
+			}
+			value = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case 2:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return errors.New("truncated field")
+			}
+			payload = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case 5:
+			if len(data) < 4 {
+				return errors.New("truncated fixed32")
+			}
+			value = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		default:
+			return fmt.Errorf("unsupported wire type %d", wireType)
+		}
+
+		if err := fn(field, wireType, value, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
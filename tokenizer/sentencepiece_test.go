@@ -0,0 +1,147 @@
+package tokenizer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func protoVarint(field int, v uint64) []byte {
+	b := binary.AppendUvarint(nil, uint64(field)<<3)
+	return binary.AppendUvarint(b, v)
+}
+
+func protoBytes(field int, payload []byte) []byte {
+	b := binary.AppendUvarint(nil, uint64(field)<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(payload)))
+	return append(b, payload...)
+}
+
+func protoFloat(field int, f float32) []byte {
+	b := binary.AppendUvarint(nil, uint64(field)<<3|5)
+	return binary.LittleEndian.AppendUint32(b, math.Float32bits(f))
+}
+
+// buildSentencePiece serializes a BPE model with identity normalization.
+func buildSentencePiece(pieces []Piece) []byte {
+	var model []byte
+	for _, p := range pieces {
+		var piece []byte
+		piece = append(piece, protoBytes(1, []byte(p.Text))...)
+		piece = append(piece, protoFloat(2, p.Score)...)
+		piece = append(piece, protoVarint(3, uint64(p.Type))...)
+		model = append(model, protoBytes(1, piece)...)
+	}
+	model = append(model, protoBytes(2, protoVarint(3, 2))...)
+
+	var normalizer []byte
+	normalizer = append(normalizer, protoBytes(1, []byte("identity"))...)
+	normalizer = append(normalizer, protoVarint(3, 1)...)
+	normalizer = append(normalizer, protoVarint(4, 0)...)
+	return append(model, protoBytes(3, normalizer)...)
+}
+
+// testSentencePiecePieces returns a small vocabulary in the layout of Mistral's v3 models: control tokens, byte
+// fallback pieces and merged pieces.
+func testSentencePiecePieces() []Piece {
+	pieces := []Piece{
+		{Text: "<unk>", Type: PieceUnknown},
+		{Text: "<s>", Type: PieceControl},
+		{Text: "</s>", Type: PieceControl},
+	}
+	for _, token := range defaultTekkenSpecialTokens[3:] {
+		pieces = append(pieces, Piece{Text: token, Type: PieceControl})
+	}
+	for b := 0; b < 256; b++ {
+		pieces = append(pieces, Piece{Text: fmt.Sprintf("<0x%02X>", b), Type: PieceByte})
+	}
+	normal := []string{"▁", "h", "e", "l", "o", "w", "r", "d", "a", "b", "c", "▁h", "he", "ll", "▁he", "▁hell", "▁hello", "or", "▁w", "▁wor", "ld", "▁world", "bc", "ab"}
+	for i, text := range normal {
+		pieces = append(pieces, Piece{Text: text, Score: -float32(i), Type: PieceNormal})
+	}
+	return pieces
+}
+
+func testSentencePiece(t *testing.T) *SentencePiece {
+	t.Helper()
+	sp, err := ParseSentencePiece(buildSentencePiece(testSentencePiecePieces()))
+	assert.NoError(t, err)
+	return sp
+}
+
+func pieceTexts(sp *SentencePiece, ids []int) []string {
+	texts := make([]string, len(ids))
+	for i, id := range ids {
+		texts[i] = sp.Pieces()[id].Text
+	}
+	return texts
+}
+
+func TestSentencePieceEncode(t *testing.T) {
+	sp := testSentencePiece(t)
+
+	ids := sp.Encode("hello world")
+	assert.Equal(t, []string{"▁hello", "▁world"}, pieceTexts(sp, ids))
+	assert.Equal(t, "hello world", sp.Decode(ids))
+
+	// "bc" has a higher score than "ab" so it is merged first.
+	assert.Equal(t, []string{"▁", "a", "bc"}, pieceTexts(sp, sp.Encode("abc")))
+
+	// Control tokens in the text are encoded as text.
+	for _, text := range pieceTexts(sp, sp.Encode("[INST]")) {
+		assert.NotEqual(t, "[INST]", text)
+	}
+}
+
+func TestSentencePieceByteFallback(t *testing.T) {
+	sp := testSentencePiece(t)
+
+	ids := sp.Encode("hé")
+	assert.Equal(t, []string{"▁h", "<0xC3>", "<0xA9>"}, pieceTexts(sp, ids))
+	assert.Equal(t, "hé", sp.Decode(ids))
+
+	bos, ok := sp.TokenID("<s>")
+	assert.True(t, ok)
+	assert.Equal(t, "hello", sp.Decode(append([]int{bos}, sp.Encode("hello")...)))
+}
+
+func TestSentencePieceUnknown(t *testing.T) {
+	var pieces []Piece
+	for _, p := range testSentencePiecePieces() {
+		if p.Type != PieceByte {
+			pieces = append(pieces, p)
+		}
+	}
+	sp, err := ParseSentencePiece(buildSentencePiece(pieces))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"▁h", "<unk>"}, pieceTexts(sp, sp.Encode("hé")))
+}
+
+func TestParseSentencePieceErrors(t *testing.T) {
+	_, err := ParseSentencePiece([]byte{0xff})
+	assert.Error(t, err)
+
+	// Unigram models are not supported.
+	data := protoBytes(1, protoBytes(1, []byte("a")))
+	_, err = ParseSentencePiece(data)
+	assert.ErrorContains(t, err, "only BPE is supported")
+}
+
+func TestLoadSentencePiece(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokenizer.model.v3")
+	assert.NoError(t, os.WriteFile(path, buildSentencePiece(testSentencePiecePieces()), 0o644))
+
+	tok, err := Load(path)
+	assert.NoError(t, err)
+	assert.IsType(t, &SentencePiece{}, tok)
+	assert.Equal(t, len(testSentencePiecePieces()), tok.VocabSize())
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.model"))
+	assert.Error(t, err)
+}
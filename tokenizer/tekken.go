@@ -0,0 +1,267 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultTekkenSpecialTokens are the special tokens of Tekken files that do not list their own.
+var defaultTekkenSpecialTokens = []string{
+	"<unk>", "<s>", "</s>", "[INST]", "[/INST]", "[AVAILABLE_TOOLS]", "[/AVAILABLE_TOOLS]", "[TOOL_RESULTS]",
+	"[/TOOL_RESULTS]", "[TOOL_CALLS]", "[IMG]", "<pad>", "[IMG_BREAK]", "[IMG_END]", "[PREFIX]", "[MIDDLE]",
+	"[SUFFIX]", "[SYSTEM_PROMPT]", "[/SYSTEM_PROMPT]", "[TOOL_CONTENT]",
+}
+
+// tekkenLookahead is the alternative of the Tekken pattern that needs a lookahead, which Go's regexp package does
+// not support. It is removed from the pattern and emulated by Tekken.split.
+const tekkenLookahead = `|\s+(?!\S)`
+
+type tekkenFile struct {
+	Config struct {
+		Pattern                 string `json:"pattern"`
+		DefaultVocabSize        int    `json:"default_vocab_size"`
+		DefaultNumSpecialTokens int    `json:"default_num_special_tokens"`
+		Version                 string `json:"version"`
+	} `json:"config"`
+	Vocab []struct {
+		Rank       int    `json:"rank"`
+		TokenBytes string `json:"token_bytes"`
+	} `json:"vocab"`
+	SpecialTokens []struct {
+		Rank     int    `json:"rank"`
+		TokenStr string `json:"token_str"`
+	} `json:"special_tokens"`
+}
+
+// Tekken is a byte-level BPE tokenizer in the tiktoken style, the format of Mistral's tekken.json files. Token ids
+// start with the special tokens, followed by the vocabulary in rank order.
+type Tekken struct {
+	pattern   *regexp.Regexp
+	lookahead bool
+	ranks     map[string]int
+	vocab     [][]byte
+	specials  []string
+	special   map[string]int
+	version   string
+}
+
+// LoadTekken loads a tekken.json file.
+func LoadTekken(path string) (*Tekken, error) {
+	data, err := readTokenizerFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTekken(data)
+}
+
+// ParseTekken parses the contents of a tekken.json file.
+func ParseTekken(data []byte) (*Tekken, error) {
+	var file tekkenFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing Tekken tokenizer: %w", err)
+	}
+	if file.Config.Pattern == "" {
+		return nil, errors.New("error parsing Tekken tokenizer: missing pattern")
+	}
+
+	t := &Tekken{
+		ranks:   map[string]int{},
+		special: map[string]int{},
+		version: file.Config.Version,
+	}
+
+	numSpecial := file.Config.DefaultNumSpecialTokens
+	if len(file.SpecialTokens) > 0 {
+		if numSpecial < len(file.SpecialTokens) {
+			numSpecial = len(file.SpecialTokens)
+		}
+		t.specials = make([]string, numSpecial)
+		for _, token := range file.SpecialTokens {
+			if token.Rank < 0 || token.Rank >= numSpecial {
+				return nil, fmt.Errorf("error parsing Tekken tokenizer: special token %q has invalid rank %d", token.TokenStr, token.Rank)
+			}
+			t.specials[token.Rank] = token.TokenStr
+		}
+	} else {
+		if numSpecial < len(defaultTekkenSpecialTokens) {
+			numSpecial = len(defaultTekkenSpecialTokens)
+		}
+		t.specials = make([]string, numSpecial)
+		copy(t.specials, defaultTekkenSpecialTokens)
+	}
+	for id, token := range t.specials {
+		if token == "" {
+			token = fmt.Sprintf("<SPECIAL_%d>", id)
+			t.specials[id] = token
+		}
+		t.special[token] = id
+	}
+
+	vocabSize := len(file.Vocab)
+	if file.Config.DefaultVocabSize > 0 && file.Config.DefaultVocabSize-numSpecial < vocabSize {
+		vocabSize = file.Config.DefaultVocabSize - numSpecial
+	}
+	t.vocab = make([][]byte, vocabSize)
+	for _, token := range file.Vocab {
+		if token.Rank < 0 || token.Rank >= vocabSize {
+			continue
+		}
+		b, err := base64.StdEncoding.DecodeString(token.TokenBytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing Tekken tokenizer: token %d: %w", token.Rank, err)
+		}
+		t.vocab[token.Rank] = b
+		t.ranks[string(b)] = token.Rank
+	}
+
+	pattern := file.Config.Pattern
+	if strings.Contains(pattern, tekkenLookahead) {
+		pattern = strings.Replace(pattern, tekkenLookahead, "", 1)
+		t.lookahead = true
+	}
+	re, err := regexp.Compile(`^(?:` + unicodeWhitespace(pattern) + `)`)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing Tekken tokenizer: invalid pattern: %w", err)
+	}
+	t.pattern = re
+
+	return t, nil
+}
+
+// Version returns the tokenizer version declared in the file, such as "v3" or "v7".
+func (t *Tekken) Version() string {
+	return t.version
+}
+
+// NumSpecialTokens returns the number of special tokens, which is also the id of the first vocabulary token.
+func (t *Tekken) NumSpecialTokens() int {
+	return len(t.specials)
+}
+
+// VocabSize returns the number of token ids, including special tokens.
+func (t *Tekken) VocabSize() int {
+	return len(t.specials) + len(t.vocab)
+}
+
+// TokenID returns the id of a special token.
+func (t *Tekken) TokenID(token string) (int, bool) {
+	id, ok := t.special[token]
+	return id, ok
+}
+
+// Encode converts text to token ids. The text is split with the tokenizer's pattern and every piece is merged by
+// rank starting from its bytes.
+func (t *Tekken) Encode(text string) []int {
+	var ids []int
+	for _, piece := range t.split(text) {
+		if rank, ok := t.ranks[piece]; ok {
+			ids = append(ids, rank+len(t.specials))
+			continue
+		}
+
+		symbols := make([]string, len(piece))
+		for i := 0; i < len(piece); i++ {
+			symbols[i] = piece[i : i+1]
+		}
+		symbols = bpeMerge(symbols, func(merged string) (float64, bool) {
+			rank, ok := t.ranks[merged]
+			return float64(rank), ok
+		})
+		for _, s := range symbols {
+			if rank, ok := t.ranks[s]; ok {
+				ids = append(ids, rank+len(t.specials))
+			} else {
+				ids = append(ids, t.special["<unk>"])
+			}
+		}
+	}
+	return ids
+}
+
+// Decode converts token ids back to text, skipping special tokens.
+func (t *Tekken) Decode(ids []int) string {
+	var b []byte
+	for _, id := range ids {
+		rank := id - len(t.specials)
+		if rank < 0 || rank >= len(t.vocab) {
+			continue
+		}
+		b = append(b, t.vocab[rank]...)
+	}
+	return string(b)
+}
+
+// split splits text into the pieces that are merged independently. A run of whitespace followed by a non-space
+// character gives up its last character to the following piece, as the lookahead of the original pattern does.
+func (t *Tekken) split(text string) []string {
+	var pieces []string
+	for len(text) > 0 {
+		end := 0
+		if loc := t.pattern.FindStringIndex(text); loc != nil {
+			end = loc[1]
+		}
+		if end == 0 {
+			_, end = utf8.DecodeRuneInString(text)
+		} else if t.lookahead && end < len(text) {
+			end = backOffWhitespace(text[:end])
+		}
+		pieces = append(pieces, text[:end])
+		text = text[end:]
+	}
+	return pieces
+}
+
+// backOffWhitespace returns the length of a match without its last character when the match is a run of more than
+// one whitespace character that does not end in a line break.
+func backOffWhitespace(match string) int {
+	if strings.HasSuffix(match, "\n") || strings.HasSuffix(match, "\r") || utf8.RuneCountInString(match) < 2 {
+		return len(match)
+	}
+	for _, r := range match {
+		if !unicode.IsSpace(r) {
+			return len(match)
+		}
+	}
+	_, last := utf8.DecodeLastRuneInString(match)
+	return len(match) - last
+}
+
+// unicodeWhitespace rewrites \s in a pattern to match all Unicode whitespace like the regex engines the pattern was
+// written for, instead of only ASCII whitespace.
+func unicodeWhitespace(pattern string) string {
+	const whitespace = `\s\x0B\x{85}\p{Z}`
+
+	var b strings.Builder
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			escape := pattern[i : i+2]
+			i++
+			switch {
+			case escape == `\s` && inClass:
+				b.WriteString(whitespace)
+			case escape == `\s`:
+				b.WriteString("[" + whitespace + "]")
+			case escape == `\S` && !inClass:
+				b.WriteString("[^" + whitespace + "]")
+			default:
+				b.WriteString(escape)
+			}
+			continue
+		case c == '[' && !inClass:
+			inClass = true
+		case c == ']' && inClass:
+			inClass = false
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const tekkenPattern = `[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*|\p{N}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+(?!\S)|\s+`
+
+// buildTekken builds a tekken.json with every byte and the given merged tokens, in rank order.
+func buildTekken(t *testing.T, specialTokens []string, merged ...string) []byte {
+	t.Helper()
+
+	type token struct {
+		Rank       int    `json:"rank"`
+		TokenBytes string `json:"token_bytes"`
+		TokenStr   string `json:"token_str,omitempty"`
+		IsControl  bool   `json:"is_control,omitempty"`
+	}
+	var vocab []token
+	for b := 0; b < 256; b++ {
+		vocab = append(vocab, token{Rank: b, TokenBytes: base64.StdEncoding.EncodeToString([]byte{byte(b)})})
+	}
+	for _, m := range merged {
+		vocab = append(vocab, token{Rank: len(vocab), TokenBytes: base64.StdEncoding.EncodeToString([]byte(m)), TokenStr: m})
+	}
+	var specials []token
+	for i, s := range specialTokens {
+		specials = append(specials, token{Rank: i, TokenStr: s, IsControl: true})
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"config": map[string]any{
+			"pattern":                    tekkenPattern,
+			"num_vocab_tokens":           len(vocab) + 100,
+			"default_vocab_size":         len(vocab) + 100,
+			"default_num_special_tokens": 100,
+			"version":                    "v7",
+		},
+		"vocab":          vocab,
+		"special_tokens": specials,
+	})
+	assert.NoError(t, err)
+	return data
+}
+
+func testTekken(t *testing.T) *Tekken {
+	t.Helper()
+	tok, err := ParseTekken(buildTekken(t, nil, "he", "ll", "llo", "hello", " w", " wor", "or", " world"))
+	assert.NoError(t, err)
+	return tok
+}
+
+func TestTekkenSplit(t *testing.T) {
+	tok := testTekken(t)
+
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"Hello  world!\n\nfoo   bar", []string{"Hello", " ", " world", "!\n\n", "foo", "  ", " bar"}},
+		{"a \n b", []string{"a", " \n", " b"}},
+		{"x  ", []string{"x", "  "}},
+		{"123abc", []string{"1", "2", "3", "abc"}},
+		{"a  b", []string{"a", " ", " b"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tok.split(tt.text), tt.text)
+	}
+}
+
+func TestTekkenEncode(t *testing.T) {
+	tok := testTekken(t)
+	assert.Equal(t, 100, tok.NumSpecialTokens())
+	assert.Equal(t, "v7", tok.Version())
+
+	rank := func(s string) int {
+		return tok.ranks[s] + tok.NumSpecialTokens()
+	}
+
+	assert.Equal(t, []int{rank("hello"), rank(" world")}, tok.Encode("hello world"))
+	// Pieces outside the vocabulary are merged by rank.
+	assert.Equal(t, []int{rank("hello"), rank("s")}, tok.Encode("hellos"))
+	assert.Equal(t, []int{rank("he"), rank("l")}, tok.Encode("hel"))
+
+	for _, text := range []string{"hello world", "Ünïcödé ✓ text\n\twith  spaces ", "[INST]"} {
+		assert.Equal(t, text, tok.Decode(tok.Encode(text)))
+	}
+}
+
+func TestTekkenSpecialTokens(t *testing.T) {
+	tok := testTekken(t)
+
+	id, ok := tok.TokenID("[INST]")
+	assert.True(t, ok)
+	assert.Equal(t, 3, id)
+	id, ok = tok.TokenID("<SPECIAL_99>")
+	assert.True(t, ok)
+	assert.Equal(t, 99, id)
+	// Special tokens are skipped when decoding.
+	assert.Equal(t, "hello", tok.Decode(append([]int{1, 3}, tok.Encode("hello")...)))
+
+	custom, err := ParseTekken(buildTekken(t, []string{"<unk>", "<s>", "</s>", "[CUSTOM]"}))
+	assert.NoError(t, err)
+	id, ok = custom.TokenID("[CUSTOM]")
+	assert.True(t, ok)
+	assert.Equal(t, 3, id)
+	_, ok = custom.TokenID("[INST]")
+	assert.False(t, ok)
+	assert.Equal(t, 356, custom.VocabSize())
+}
+
+func TestParseTekkenErrors(t *testing.T) {
+	_, err := ParseTekken([]byte(`{`))
+	assert.Error(t, err)
+	_, err = ParseTekken([]byte(`{"config":{},"vocab":[]}`))
+	assert.ErrorContains(t, err, "missing pattern")
+}
@@ -0,0 +1,292 @@
+package tokenizer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/gage-technologies/mistral-go"
+)
+
+// Version identifies the instruct format of a tokenizer.
+type Version int
+
+const (
+	V1 Version = 1 // [INST] markers encoded as text, no tools.
+	V2 Version = 2 // [INST], tool and tool result control tokens.
+	V3 Version = 3 // Like V2 with tool call ids.
+	V7 Version = 7 // Like V3 with system prompt tokens and [TOOL_CONTENT] in tool results.
+)
+
+// ParseVersion parses a version such as "v3".
+func ParseVersion(s string) (Version, error) {
+	switch strings.ToLower(s) {
+	case "v1":
+		return V1, nil
+	case "v2":
+		return V2, nil
+	case "v3":
+		return V3, nil
+	case "v7":
+		return V7, nil
+	}
+	return 0, fmt.Errorf("unsupported tokenizer version %q", s)
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("v%d", int(v))
+}
+
+// ChatTemplate applies Mistral's instruct format to conversations, so the prompt tokens of a chat request can be
+// counted before it is sent.
+//
+// Only text is counted: image and document parts of messages are ignored.
+type ChatTemplate struct {
+	tokenizer Tokenizer
+	version   Version
+	special   map[string]int
+}
+
+// NewChatTemplate creates a chat template for a tokenizer. It fails when the tokenizer lacks the special tokens of
+// the version.
+func NewChatTemplate(tok Tokenizer, version Version) (*ChatTemplate, error) {
+	required := []string{"<s>", "</s>"}
+	switch version {
+	case V1:
+	case V2, V3:
+		required = append(required, "[INST]", "[/INST]", "[AVAILABLE_TOOLS]", "[/AVAILABLE_TOOLS]", "[TOOL_CALLS]", "[TOOL_RESULTS]", "[/TOOL_RESULTS]")
+	case V7:
+		required = append(required, "[INST]", "[/INST]", "[AVAILABLE_TOOLS]", "[/AVAILABLE_TOOLS]", "[TOOL_CALLS]", "[TOOL_RESULTS]", "[/TOOL_RESULTS]",
+			"[TOOL_CONTENT]", "[SYSTEM_PROMPT]", "[/SYSTEM_PROMPT]")
+	default:
+		return nil, fmt.Errorf("unsupported tokenizer version %s", version)
+	}
+
+	t := &ChatTemplate{tokenizer: tok, version: version, special: map[string]int{}}
+	for _, token := range required {
+		id, ok := tok.TokenID(token)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is required by the %s template", ErrUnknownToken, token, version)
+		}
+		t.special[token] = id
+	}
+	return t, nil
+}
+
+// Tokenizer returns the tokenizer of the template.
+func (t *ChatTemplate) Tokenizer() Tokenizer {
+	return t.tokenizer
+}
+
+// Version returns the instruct format version of the template.
+func (t *ChatTemplate) Version() Version {
+	return t.version
+}
+
+// CountTokens returns the number of prompt tokens of a conversation.
+func (t *ChatTemplate) CountTokens(messages []mistral.ChatMessage) (int, error) {
+	return t.CountTokensWithTools(messages, nil)
+}
+
+// CountTokensWithTools returns the number of prompt tokens of a conversation sent with tool definitions.
+func (t *ChatTemplate) CountTokensWithTools(messages []mistral.ChatMessage, tools []mistral.Tool) (int, error) {
+	ids, err := t.EncodeChat(messages, tools)
+	return len(ids), err
+}
+
+// EncodeChat encodes a conversation and its tool definitions the way the model sees them. System messages are
+// merged into the last user message before V7. Tool definitions are placed before the last user message.
+func (t *ChatTemplate) EncodeChat(messages []mistral.ChatMessage, tools []mistral.Tool) ([]int, error) {
+	if len(tools) > 0 && t.version == V1 {
+		return nil, fmt.Errorf("the %s template does not support tools", t.version)
+	}
+
+	lastUser := -1
+	var systemPrompts []string
+	for i, msg := range messages {
+		switch msg.Role {
+		case mistral.RoleUser:
+			lastUser = i
+		case mistral.RoleSystem:
+			systemPrompts = append(systemPrompts, messageText(msg))
+		}
+	}
+
+	ids := []int{t.special["<s>"]}
+	for i, msg := range messages {
+		switch msg.Role {
+		case mistral.RoleSystem:
+			if t.version >= V7 {
+				ids = append(ids, t.special["[SYSTEM_PROMPT]"])
+				ids = append(ids, t.tokenizer.Encode(messageText(msg))...)
+				ids = append(ids, t.special["[/SYSTEM_PROMPT]"])
+			}
+
+		case mistral.RoleUser:
+			content := messageText(msg)
+			if i == lastUser {
+				if len(tools) > 0 {
+					encoded, err := pythonJSON(tools)
+					if err != nil {
+						return nil, err
+					}
+					ids = append(ids, t.special["[AVAILABLE_TOOLS]"])
+					ids = append(ids, t.tokenizer.Encode(encoded)...)
+					ids = append(ids, t.special["[/AVAILABLE_TOOLS]"])
+				}
+				if t.version < V7 && len(systemPrompts) > 0 {
+					content = strings.Join(systemPrompts, "\n\n") + "\n\n" + content
+				}
+			}
+			if t.version == V1 {
+				ids = append(ids, t.tokenizer.Encode("[INST] "+content+" [/INST]")...)
+			} else {
+				ids = append(ids, t.special["[INST]"])
+				ids = append(ids, t.tokenizer.Encode(content)...)
+				ids = append(ids, t.special["[/INST]"])
+			}
+
+		case mistral.RoleAssistant:
+			if content := messageText(msg); content != "" {
+				ids = append(ids, t.tokenizer.Encode(content)...)
+			}
+			if len(msg.ToolCalls) > 0 {
+				encoded, err := t.encodeToolCalls(msg.ToolCalls)
+				if err != nil {
+					return nil, err
+				}
+				ids = append(ids, t.special["[TOOL_CALLS]"])
+				ids = append(ids, t.tokenizer.Encode(encoded)...)
+			}
+			ids = append(ids, t.special["</s>"])
+
+		case mistral.RoleTool:
+			if t.version == V1 {
+				return nil, fmt.Errorf("the %s template does not support tool messages", t.version)
+			}
+			encoded, err := t.encodeToolResult(msg)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, encoded...)
+
+		default:
+			return nil, fmt.Errorf("unsupported message role %q", msg.Role)
+		}
+	}
+	return ids, nil
+}
+
+func (t *ChatTemplate) encodeToolCalls(calls []mistral.ToolCall) (string, error) {
+	type encodedCall struct {
+		Name      string `json:"name"`
+		Arguments any    `json:"arguments"`
+		ID        string `json:"id,omitempty"`
+	}
+
+	encoded := make([]encodedCall, len(calls))
+	for i, call := range calls {
+		encoded[i] = encodedCall{Name: call.Function.Name, Arguments: call.Function.Arguments}
+		var arguments map[string]any
+		if json.Unmarshal([]byte(call.Function.Arguments), &arguments) == nil {
+			encoded[i].Arguments = json.RawMessage(call.Function.Arguments)
+		}
+		if t.version >= V3 {
+			encoded[i].ID = call.Id
+		}
+	}
+	return pythonJSON(encoded)
+}
+
+func (t *ChatTemplate) encodeToolResult(msg mistral.ChatMessage) ([]int, error) {
+	content := messageText(msg)
+	ids := []int{t.special["[TOOL_RESULTS]"]}
+
+	switch {
+	case t.version >= V7:
+		ids = append(ids, t.tokenizer.Encode(msg.ToolCallId)...)
+		ids = append(ids, t.special["[TOOL_CONTENT]"])
+		ids = append(ids, t.tokenizer.Encode(content)...)
+	case t.version >= V3:
+		encoded, err := pythonJSON(struct {
+			Content string `json:"content"`
+			CallID  string `json:"call_id"`
+		}{content, msg.ToolCallId})
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, t.tokenizer.Encode(encoded)...)
+	default:
+		encoded, err := pythonJSON(struct {
+			Name    string `json:"name"`
+			Content string `json:"content"`
+		}{msg.Name, content})
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, t.tokenizer.Encode(encoded)...)
+	}
+
+	return append(ids, t.special["[/TOOL_RESULTS]"]), nil
+}
+
+// messageText returns the text of a message, joining its text parts.
+func messageText(msg mistral.ChatMessage) string {
+	if len(msg.ContentParts) == 0 {
+		return msg.Content
+	}
+	var texts []string
+	for _, part := range msg.ContentParts {
+		if part.Type == mistral.ContentTypeText {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// pythonJSON encodes v the way Python's json.dumps does by default, which is how Mistral's reference implementation
+// renders tools and tool calls: with spaces after separators and non-ASCII characters escaped.
+func pythonJSON(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", fmt.Errorf("error encoding JSON: %w", err)
+	}
+	compact := bytes.TrimSpace(buf.Bytes())
+
+	var b strings.Builder
+	inString := false
+	for i := 0; i < len(compact); {
+		c := compact[i]
+		switch {
+		case inString && c == '\\':
+			b.Write(compact[i : i+2])
+			i += 2
+			continue
+		case c == '"':
+			inString = !inString
+		case !inString && (c == ',' || c == ':'):
+			b.WriteByte(c)
+			b.WriteByte(' ')
+			i++
+			continue
+		case c >= utf8.RuneSelf:
+			r, n := utf8.DecodeRune(compact[i:])
+			if r > 0xFFFF {
+				r1, r2 := utf16.EncodeRune(r)
+				fmt.Fprintf(&b, `\u%04x\u%04x`, r1, r2)
+			} else {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			}
+			i += n
+			continue
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,134 @@
+package tokenizer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gage-technologies/mistral-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// specialNames returns the special tokens of a Tekken encoding, dropping ordinary tokens.
+func specialNames(tok *Tekken, ids []int) []string {
+	var names []string
+	for _, id := range ids {
+		if id < tok.NumSpecialTokens() {
+			names = append(names, tok.specials[id])
+		}
+	}
+	return names
+}
+
+func TestChatTemplateV7(t *testing.T) {
+	tok := testTekken(t)
+	template, err := NewChatTemplate(tok, V7)
+	assert.NoError(t, err)
+
+	call := mistral.ToolCall{Id: "abc123def", Type: mistral.ToolTypeFunction, Function: mistral.FunctionCall{Name: "get_weather", Arguments: `{"city": "Paris"}`}}
+	messages := []mistral.ChatMessage{
+		mistral.SystemMessage("Be brief."),
+		mistral.UserMessage("Weather in Paris?"),
+		{Role: mistral.RoleAssistant, ToolCalls: []mistral.ToolCall{call}},
+		{Role: mistral.RoleTool, Content: "sunny", ToolCallId: call.Id, Name: "get_weather"},
+		mistral.AssistantMessage("It is sunny."),
+		mistral.UserMessage("Thanks"),
+	}
+	tools := []mistral.Tool{{Type: mistral.ToolTypeFunction, Function: mistral.Function{Name: "get_weather", Parameters: map[string]any{"type": "object"}}}}
+
+	ids, err := template.EncodeChat(messages, tools)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"<s>", "[SYSTEM_PROMPT]", "[/SYSTEM_PROMPT]",
+		"[INST]", "[/INST]",
+		"[TOOL_CALLS]", "</s>",
+		"[TOOL_RESULTS]", "[TOOL_CONTENT]", "[/TOOL_RESULTS]",
+		"</s>",
+		"[AVAILABLE_TOOLS]", "[/AVAILABLE_TOOLS]", "[INST]", "[/INST]",
+	}, specialNames(tok, ids))
+	assert.Equal(t,
+		`Be brief.Weather in Paris?[{"name": "get_weather", "arguments": {"city": "Paris"}, "id": "abc123def"}]abc123defsunnyIt is sunny.`+
+			`[{"type": "function", "function": {"name": "get_weather", "description": "", "parameters": {"type": "object"}}}]Thanks`,
+		tok.Decode(ids))
+
+	n, err := template.CountTokensWithTools(messages, tools)
+	assert.NoError(t, err)
+	assert.Equal(t, len(ids), n)
+}
+
+func TestChatTemplateV3(t *testing.T) {
+	tok := testTekken(t)
+	template, err := NewChatTemplate(tok, V3)
+	assert.NoError(t, err)
+
+	ids, err := template.EncodeChat([]mistral.ChatMessage{
+		mistral.SystemMessage("Be brief."),
+		mistral.UserMessage("Hi"),
+		mistral.AssistantMessage("Hello"),
+		mistral.UserMessage("Bye"),
+		{Role: mistral.RoleTool, Content: "ok", ToolCallId: "abc123def"},
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"<s>", "[INST]", "[/INST]", "</s>", "[INST]", "[/INST]", "[TOOL_RESULTS]", "[/TOOL_RESULTS]"}, specialNames(tok, ids))
+	// The system prompt is merged into the last user message.
+	assert.Equal(t, "HiHelloBe brief.\n\nBye{\"content\": \"ok\", \"call_id\": \"abc123def\"}", tok.Decode(ids))
+}
+
+func TestChatTemplateV1(t *testing.T) {
+	sp := testSentencePiece(t)
+	template, err := NewChatTemplate(sp, V1)
+	assert.NoError(t, err)
+
+	ids, err := template.EncodeChat([]mistral.ChatMessage{
+		mistral.UserMessage("hello"),
+		mistral.AssistantMessage("world"),
+	}, nil)
+	assert.NoError(t, err)
+	bos, _ := sp.TokenID("<s>")
+	eos, _ := sp.TokenID("</s>")
+	assert.Equal(t, bos, ids[0])
+	assert.Equal(t, eos, ids[len(ids)-1])
+	// The [INST] markers are encoded as text.
+	assert.Equal(t, "[INST] hello [/INST] world", sp.Decode(ids))
+
+	_, err = template.EncodeChat([]mistral.ChatMessage{mistral.UserMessage("hi")}, []mistral.Tool{{Type: mistral.ToolTypeFunction}})
+	assert.Error(t, err)
+}
+
+func TestNewChatTemplateMissingTokens(t *testing.T) {
+	tok, err := ParseTekken(buildTekken(t, []string{"<unk>", "<s>", "</s>"}))
+	assert.NoError(t, err)
+
+	_, err = NewChatTemplate(tok, V1)
+	assert.NoError(t, err)
+	_, err = NewChatTemplate(tok, V3)
+	assert.True(t, errors.Is(err, ErrUnknownToken))
+	_, err = NewChatTemplate(tok, Version(5))
+	assert.Error(t, err)
+}
+
+func TestPythonJSON(t *testing.T) {
+	s, err := pythonJSON(map[string]any{"a": []int{1, 2}, "b": "é, <ok>: \"😀\""})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a": [1, 2], "b": "\u00e9, <ok>: \"\ud83d\ude00\""}`, s)
+}
+
+func TestLoadModel(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "tekken.json"), buildTekken(t, nil), 0o644))
+
+	template, err := LoadModel(dir, mistral.ModelMistralSmallLatest)
+	assert.NoError(t, err)
+	assert.Equal(t, V7, template.Version())
+
+	RegisterModel("my-fine-tune", ModelTokenizer{File: "tekken.json", Version: V3})
+	template, err = LoadModel(dir, "my-fine-tune")
+	assert.NoError(t, err)
+	assert.Equal(t, V3, template.Version())
+
+	_, err = LoadModel(dir, "unknown-model")
+	assert.True(t, errors.Is(err, ErrUnknownModel))
+	_, err = LoadModel(dir, mistral.ModelOpenMistral7b)
+	assert.Error(t, err)
+}
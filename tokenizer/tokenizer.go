@@ -0,0 +1,139 @@
+// Package tokenizer implements Mistral's tokenizers in pure Go so prompts can be measured locally before they are
+// sent to the API.
+//
+// Both tokenizer formats published with Mistral's models are supported: SentencePiece BPE models
+// (tokenizer.model.v1, tokenizer.model.v3, ...) and Tekken (tekken.json). A ChatTemplate applies the instruct format
+// of a tokenizer version to a conversation to count its prompt tokens:
+//
+//	template, err := tokenizer.LoadModel("/path/to/tokenizers", mistral.ModelOpenMixtral8x22b)
+//	if err != nil {
+//		return err
+//	}
+//	n, err := template.CountTokens(messages)
+package tokenizer
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrUnknownToken is returned when a special token required by a chat template is missing from the vocabulary.
+var ErrUnknownToken = errors.New("token not in vocabulary")
+
+// Tokenizer converts between text and token ids.
+type Tokenizer interface {
+	// Encode converts text to token ids. Special tokens are never produced: text that looks like a special token is
+	// encoded as ordinary text.
+	Encode(text string) []int
+	// Decode converts token ids back to text, skipping control tokens.
+	Decode(ids []int) string
+	// TokenID returns the id of a token given its text, such as "<s>" or "[INST]".
+	TokenID(token string) (int, bool)
+	// VocabSize returns the number of token ids, including special tokens.
+	VocabSize() int
+}
+
+// Load loads a tokenizer file, detecting its format from the file name: files ending in .json are loaded as Tekken
+// and anything else as a SentencePiece model.
+func Load(path string) (Tokenizer, error) {
+	if strings.HasSuffix(path, ".json") {
+		return LoadTekken(path)
+	}
+	return LoadSentencePiece(path)
+}
+
+func readTokenizerFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading tokenizer: %w", err)
+	}
+	return data, nil
+}
+
+// bpeMerge repeatedly merges the adjacent pair of symbols with the lowest priority, leftmost first, until no pair
+// can be merged. priority reports the priority of a merged symbol, or false if the merge is not allowed.
+func bpeMerge(symbols []string, priority func(merged string) (float64, bool)) []string {
+	if len(symbols) < 2 {
+		return symbols
+	}
+
+	type symbol struct {
+		text       string
+		prev, next int
+	}
+	list := make([]symbol, len(symbols))
+	for i, s := range symbols {
+		list[i] = symbol{text: s, prev: i - 1, next: i + 1}
+	}
+	list[len(list)-1].next = -1
+
+	queue := &mergeQueue{}
+	push := func(left int) {
+		if left < 0 || list[left].next < 0 {
+			return
+		}
+		merged := list[left].text + list[list[left].next].text
+		if p, ok := priority(merged); ok {
+			heap.Push(queue, mergeCandidate{left: left, right: list[left].next, merged: merged, priority: p})
+		}
+	}
+	for i := range list {
+		push(i)
+	}
+
+	for queue.Len() > 0 {
+		c := heap.Pop(queue).(mergeCandidate)
+		left, right := &list[c.left], &list[c.right]
+		// Skip candidates made stale by an earlier merge.
+		if left.text == "" || left.next != c.right || left.text+right.text != c.merged {
+			continue
+		}
+
+		left.text = c.merged
+		left.next = right.next
+		if right.next >= 0 {
+			list[right.next].prev = c.left
+		}
+		right.text = ""
+
+		push(left.prev)
+		push(c.left)
+	}
+
+	merged := make([]string, 0, len(symbols))
+	for i := 0; i >= 0; i = list[i].next {
+		merged = append(merged, list[i].text)
+	}
+	return merged
+}
+
+type mergeCandidate struct {
+	left, right int
+	merged      string
+	priority    float64
+}
+
+type mergeQueue []mergeCandidate
+
+func (q mergeQueue) Len() int { return len(q) }
+
+func (q mergeQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority < q[j].priority
+	}
+	return q[i].left < q[j].left
+}
+
+func (q mergeQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *mergeQueue) Push(x any) { *q = append(*q, x.(mergeCandidate)) }
+
+func (q *mergeQueue) Pop() any {
+	old := *q
+	c := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return c
+}
@@ -0,0 +1,179 @@
+package mistral
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrUnknownTool is returned when the model calls a tool that is not registered.
+var ErrUnknownTool = errors.New("unknown tool")
+
+// ToolHandler executes a tool call and returns its result. The result is sent back to the model as described by
+// ToolResultMessage.
+type ToolHandler func(ctx context.Context, call ToolCall) (any, error)
+
+// ToolOption configures a tool registered with ToolRegistry.Register.
+type ToolOption func(*registeredTool)
+
+// WithToolTimeout bounds how long a single call of the tool may run. The handler's context is cancelled once the
+// timeout expires.
+func WithToolTimeout(timeout time.Duration) ToolOption {
+	return func(t *registeredTool) {
+		t.timeout = timeout
+	}
+}
+
+// WithoutArgumentValidation disables validating the arguments of calls against the function's parameters schema
+// before the handler runs.
+func WithoutArgumentValidation() ToolOption {
+	return func(t *registeredTool) {
+		t.skipValidation = true
+	}
+}
+
+type registeredTool struct {
+	function       Function
+	handler        ToolHandler
+	timeout        time.Duration
+	skipValidation bool
+}
+
+// ToolRegistry binds Function definitions to the Go handlers that execute them. It is safe for concurrent use.
+type ToolRegistry struct {
+	mu    sync.RWMutex
+	tools map[string]*registeredTool
+	order []string
+}
+
+// NewToolRegistry creates an empty tool registry.
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{
+		tools: map[string]*registeredTool{},
+	}
+}
+
+// Register adds a tool to the registry. Tool names must be unique.
+func (r *ToolRegistry) Register(function Function, handler ToolHandler, opts ...ToolOption) error {
+	if function.Name == "" {
+		return errors.New("tool function must have a name")
+	}
+	if handler == nil {
+		return fmt.Errorf("tool %s has no handler", function.Name)
+	}
+
+	tool := &registeredTool{function: function, handler: handler}
+	for _, opt := range opts {
+		opt(tool)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tools[function.Name]; ok {
+		return fmt.Errorf("tool %s is already registered", function.Name)
+	}
+	r.tools[function.Name] = tool
+	r.order = append(r.order, function.Name)
+	return nil
+}
+
+// Tools returns the definitions of the registered tools in registration order, ready to be set on
+// ChatRequestParams.Tools.
+func (r *ToolRegistry) Tools() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tools := make([]Tool, 0, len(r.order))
+	for _, name := range r.order {
+		tools = append(tools, Tool{Type: ToolTypeFunction, Function: r.tools[name].function})
+	}
+	return tools
+}
+
+// Execute validates the arguments of the call against the function's parameters schema, runs the handler of the
+// called tool and returns the tool result message to send back to the model. When the tool is unknown, the
+// arguments are invalid, or the handler fails, panics or times out, the message describes the error so the model
+// can react to it, and the error is returned as well. Invalid arguments are reported as a *SchemaValidationError.
+func (r *ToolRegistry) Execute(ctx context.Context, call ToolCall) (ChatMessage, error) {
+	return r.execute(ctx, call, 0)
+}
+
+// execute is like Execute but applies defaultTimeout to tools registered without a timeout.
+func (r *ToolRegistry) execute(ctx context.Context, call ToolCall, defaultTimeout time.Duration) (ChatMessage, error) {
+	r.mu.RLock()
+	tool, ok := r.tools[call.Function.Name]
+	r.mu.RUnlock()
+	if !ok {
+		err := fmt.Errorf("%w: %s", ErrUnknownTool, call.Function.Name)
+		return toolErrorMessage(call, err), err
+	}
+
+	if !tool.skipValidation {
+		if err := ValidateToolArguments(tool.function, call.Function); err != nil {
+			err = fmt.Errorf("invalid arguments for %s: %w", call.Function.Name, err)
+			msg := toolErrorMessage(call, err)
+			msg.Content += ". Fix the arguments and call the tool again."
+			return msg, err
+		}
+	}
+
+	timeout := tool.timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	result, err := callToolHandler(ctx, tool.handler, call)
+	if err != nil {
+		err = fmt.Errorf("tool %s failed: %w", call.Function.Name, err)
+		return toolErrorMessage(call, err), err
+	}
+
+	msg, err := ToolResultMessage(call, result)
+	if err != nil {
+		return toolErrorMessage(call, err), err
+	}
+	return msg, nil
+}
+
+// callToolHandler runs the handler, turning a panic into an error and returning early if the context is done
+// before the handler returns.
+func callToolHandler(ctx context.Context, handler ToolHandler, call ToolCall) (any, error) {
+	type outcome struct {
+		result any
+		err    error
+	}
+	done := make(chan outcome, 1)
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- outcome{err: fmt.Errorf("panic: %v", p)}
+			}
+		}()
+		result, err := handler(ctx, call)
+		done <- outcome{result: result, err: err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.result, o.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func toolErrorMessage(call ToolCall, err error) ChatMessage {
+	return ChatMessage{
+		Role:       RoleTool,
+		Content:    "error: " + err.Error(),
+		ToolCallId: call.Id,
+		Name:       call.Function.Name,
+	}
+}
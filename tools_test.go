@@ -0,0 +1,56 @@
+package mistral
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolRegistry(t *testing.T) {
+	registry := NewToolRegistry()
+	weather := Function{Name: "get_weather", Description: "Retrieve the weather for a city"}
+	assert.NoError(t, registry.Register(weather, func(ctx context.Context, call ToolCall) (any, error) {
+		return map[string]interface{}{"temperature": 82}, nil
+	}))
+	assert.NoError(t, registry.Register(Function{Name: "fail"}, func(ctx context.Context, call ToolCall) (any, error) {
+		return nil, errors.New("boom")
+	}))
+	assert.NoError(t, registry.Register(Function{Name: "panic"}, func(ctx context.Context, call ToolCall) (any, error) {
+		panic("oops")
+	}))
+	assert.NoError(t, registry.Register(Function{Name: "slow"}, func(ctx context.Context, call ToolCall) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, WithToolTimeout(10*time.Millisecond)))
+
+	assert.Error(t, registry.Register(weather, func(ctx context.Context, call ToolCall) (any, error) { return nil, nil }))
+	assert.Error(t, registry.Register(Function{}, func(ctx context.Context, call ToolCall) (any, error) { return nil, nil }))
+	assert.Error(t, registry.Register(Function{Name: "nil"}, nil))
+
+	tools := registry.Tools()
+	assert.Len(t, tools, 4)
+	assert.Equal(t, Tool{Type: ToolTypeFunction, Function: weather}, tools[0])
+
+	ctx := context.Background()
+	msg, err := registry.Execute(ctx, ToolCall{Id: "1", Function: FunctionCall{Name: "get_weather"}})
+	assert.NoError(t, err)
+	assert.Equal(t, ChatMessage{Role: RoleTool, Content: `{"temperature":82}`, ToolCallId: "1", Name: "get_weather"}, msg)
+
+	msg, err = registry.Execute(ctx, ToolCall{Id: "2", Function: FunctionCall{Name: "fail"}})
+	assert.ErrorContains(t, err, "boom")
+	assert.Equal(t, "error: tool fail failed: boom", msg.Content)
+	assert.Equal(t, "2", msg.ToolCallId)
+
+	_, err = registry.Execute(ctx, ToolCall{Id: "3", Function: FunctionCall{Name: "panic"}})
+	assert.ErrorContains(t, err, "panic: oops")
+
+	_, err = registry.Execute(ctx, ToolCall{Id: "4", Function: FunctionCall{Name: "slow"}})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	msg, err = registry.Execute(ctx, ToolCall{Id: "5", Function: FunctionCall{Name: "missing"}})
+	assert.ErrorIs(t, err, ErrUnknownTool)
+	assert.Equal(t, RoleTool, msg.Role)
+}
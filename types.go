@@ -5,10 +5,10 @@ const (
 	ModelMistralMediumLatest = "mistral-medium-latest"
 	ModelMistralSmallLatest  = "mistral-small-latest"
 	ModelCodestralLatest     = "codestral-latest"
-	
-	ModelOpenMixtral8x7b     = "open-mixtral-8x7b"
-	ModelOpenMixtral8x22b    = "open-mixtral-8x22b"
-	ModelOpenMistral7b       = "open-mistral-7b"
+
+	ModelOpenMixtral8x7b  = "open-mixtral-8x7b"
+	ModelOpenMixtral8x22b = "open-mixtral-8x22b"
+	ModelOpenMistral7b    = "open-mistral-7b"
 
 	ModelMistralLarge2402  = "mistral-large-2402"
 	ModelMistralMedium2312 = "mistral-medium-2312"
@@ -31,6 +31,8 @@ const (
 	FinishReasonStop   FinishReason = "stop"
 	FinishReasonLength FinishReason = "length"
 	FinishReasonError  FinishReason = "error"
+
+	FinishReasonToolCalls FinishReason = "tool_calls"
 )
 
 // ResponseFormat the format that the response must adhere to
@@ -39,6 +41,29 @@ type ResponseFormat string
 const (
 	ResponseFormatText       ResponseFormat = "text"
 	ResponseFormatJsonObject ResponseFormat = "json_object"
+	ResponseFormatJsonSchema ResponseFormat = "json_schema"
+)
+
+// JSONSchemaFormat the JSON schema that the response must adhere to when using ResponseFormatJsonSchema
+type JSONSchemaFormat struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      any    `json:"schema"`
+	Strict      bool   `json:"strict"`
+}
+
+// PredictionType the type of a predicted output
+type PredictionType string
+
+const (
+	PredictionTypeContent PredictionType = "content"
+)
+
+// PromptMode the system prompt preset used by reasoning models
+type PromptMode string
+
+const (
+	PromptModeReasoning PromptMode = "reasoning"
 )
 
 // ToolType type of tool defined for the llm
@@ -78,6 +103,7 @@ type ToolCall struct {
 	Id       string       `json:"id"`
 	Type     ToolType     `json:"type"`
 	Function FunctionCall `json:"function"`
+	Index    int          `json:"index,omitempty"` // The position of the call in the message, used to merge streamed deltas.
 }
 
 // DeltaMessage represents the delta between the prior state of the message and the new state of the message when streaming responses.
@@ -88,8 +114,12 @@ type DeltaMessage struct {
 }
 
 // ChatMessage represents a single message in a chat.
+// Multimodal content such as images is sent by setting ContentParts, which takes precedence over Content.
 type ChatMessage struct {
-	Role      string     `json:"role"`
-	Content   string     `json:"content"`
-	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Role         string        `json:"role"`
+	Content      string        `json:"content"`
+	ContentParts []ContentPart `json:"-"`
+	ToolCalls    []ToolCall    `json:"tool_calls,omitempty"`
+	ToolCallId   string        `json:"tool_call_id,omitempty"` // The id of the tool call a RoleTool message answers.
+	Name         string        `json:"name,omitempty"`         // The name of the tool that produced a RoleTool message.
 }
@@ -0,0 +1,326 @@
+package mistral
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SchemaViolation describes a single place where a JSON value does not match its schema.
+type SchemaViolation struct {
+	Path    string `json:"path"` // The location of the offending value, e.g. "$.address.city" or "$.tags[2]".
+	Message string `json:"message"`
+}
+
+// SchemaValidationError is returned when a JSON value does not match its schema.
+type SchemaValidationError struct {
+	Violations []SchemaViolation
+}
+
+func (e *SchemaValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Path + ": " + v.Message
+	}
+	return "schema validation failed: " + strings.Join(messages, "; ")
+}
+
+// ValidateJSON validates a JSON document against a JSON schema. The schema may be any value that encodes to a JSON
+// schema, such as the map returned by JSONSchemaFor or a hand written map[string]interface{}.
+//
+// A subset of JSON Schema draft 2020-12 is supported: type, enum, const, required, properties,
+// additionalProperties, items, minItems, maxItems, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// minLength, maxLength, pattern, allOf, anyOf and oneOf. Unknown keywords are ignored.
+// It returns a *SchemaValidationError when the document does not match.
+func ValidateJSON(schema any, data []byte) error {
+	compiled, err := normalizeSchema(schema)
+	if err != nil {
+		return err
+	}
+
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return &SchemaValidationError{Violations: []SchemaViolation{{Path: "$", Message: "invalid JSON: " + err.Error()}}}
+	}
+
+	v := schemaValidator{}
+	v.validate(compiled, value, "$")
+	if len(v.violations) > 0 {
+		return &SchemaValidationError{Violations: v.violations}
+	}
+	return nil
+}
+
+// ValidateToolArguments validates the arguments of a call against the parameters schema of the function.
+// Functions without parameters accept any arguments.
+func ValidateToolArguments(function Function, call FunctionCall) error {
+	if function.Parameters == nil {
+		return nil
+	}
+	arguments := call.Arguments
+	if strings.TrimSpace(arguments) == "" {
+		arguments = "{}"
+	}
+	return ValidateJSON(function.Parameters, []byte(arguments))
+}
+
+// normalizeSchema round trips a schema through JSON so it can be walked as plain maps, slices and float64s.
+func normalizeSchema(schema any) (any, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	var normalized any
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return normalized, nil
+}
+
+type schemaValidator struct {
+	violations []SchemaViolation
+}
+
+func (v *schemaValidator) fail(path string, format string, args ...any) {
+	v.violations = append(v.violations, SchemaViolation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// valid reports whether value matches schema without recording violations.
+func (v *schemaValidator) valid(schema any, value any, path string) bool {
+	sub := schemaValidator{}
+	sub.validate(schema, value, path)
+	return len(sub.violations) == 0
+}
+
+func (v *schemaValidator) validate(schema any, value any, path string) {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.fail(path, "no value is allowed here")
+		}
+		return
+	case map[string]any:
+		v.validateObjectSchema(s, value, path)
+	}
+}
+
+func (v *schemaValidator) validateObjectSchema(s map[string]any, value any, path string) {
+	if t, ok := s["type"]; ok && !matchesType(t, value) {
+		v.fail(path, "expected %s but got %s", describeType(t), jsonTypeOf(value))
+		return
+	}
+
+	if enum, ok := s["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "value must be one of %s", compactJSON(enum))
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, value) {
+		v.fail(path, "value must be %s", compactJSON(c))
+	}
+
+	switch val := value.(type) {
+	case map[string]any:
+		v.validateObject(s, val, path)
+	case []any:
+		v.validateArray(s, val, path)
+	case string:
+		v.validateString(s, val, path)
+	case float64:
+		v.validateNumber(s, val, path)
+	}
+
+	if all, ok := s["allOf"].([]any); ok {
+		for _, sub := range all {
+			v.validate(sub, value, path)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if v.valid(sub, value, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "value does not match any of the allowed schemas")
+		}
+	}
+	if oneOf, ok := s["oneOf"].([]any); ok {
+		matches := 0
+		for _, sub := range oneOf {
+			if v.valid(sub, value, path) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			v.fail(path, "value must match exactly one schema but matches %d", matches)
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(s map[string]any, value map[string]any, path string) {
+	if required, ok := s["required"].([]any); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, ok := value[name]; !ok {
+				v.fail(path+"."+name, "required property is missing")
+			}
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]any)
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		childPath := path + "." + name
+		if propSchema, ok := properties[name]; ok {
+			v.validate(propSchema, value[name], childPath)
+			continue
+		}
+		switch additional := s["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.fail(childPath, "additional property is not allowed")
+			}
+		case map[string]any:
+			v.validate(additional, value[name], childPath)
+		}
+	}
+}
+
+func (v *schemaValidator) validateArray(s map[string]any, value []any, path string) {
+	if n, ok := schemaNumber(s, "minItems"); ok && float64(len(value)) < n {
+		v.fail(path, "array must have at least %v items", n)
+	}
+	if n, ok := schemaNumber(s, "maxItems"); ok && float64(len(value)) > n {
+		v.fail(path, "array must have at most %v items", n)
+	}
+	if items, ok := s["items"]; ok {
+		for i, item := range value {
+			v.validate(items, item, path+"["+strconv.Itoa(i)+"]")
+		}
+	}
+}
+
+func (v *schemaValidator) validateString(s map[string]any, value string, path string) {
+	length := float64(utf8.RuneCountInString(value))
+	if n, ok := schemaNumber(s, "minLength"); ok && length < n {
+		v.fail(path, "string must be at least %v characters long", n)
+	}
+	if n, ok := schemaNumber(s, "maxLength"); ok && length > n {
+		v.fail(path, "string must be at most %v characters long", n)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			v.fail(path, "schema pattern %q is invalid: %v", pattern, err)
+		} else if !re.MatchString(value) {
+			v.fail(path, "string does not match pattern %q", pattern)
+		}
+	}
+}
+
+func (v *schemaValidator) validateNumber(s map[string]any, value float64, path string) {
+	if n, ok := schemaNumber(s, "minimum"); ok && value < n {
+		v.fail(path, "value must be >= %v", n)
+	}
+	if n, ok := schemaNumber(s, "maximum"); ok && value > n {
+		v.fail(path, "value must be <= %v", n)
+	}
+	if n, ok := schemaNumber(s, "exclusiveMinimum"); ok && value <= n {
+		v.fail(path, "value must be > %v", n)
+	}
+	if n, ok := schemaNumber(s, "exclusiveMaximum"); ok && value >= n {
+		v.fail(path, "value must be < %v", n)
+	}
+}
+
+func schemaNumber(s map[string]any, keyword string) (float64, bool) {
+	n, ok := s[keyword].(float64)
+	return n, ok
+}
+
+// matchesType reports whether value matches the type keyword, which is a type name or a list of them.
+func matchesType(t any, value any) bool {
+	switch t := t.(type) {
+	case string:
+		return matchesTypeName(t, value)
+	case []any:
+		for _, name := range t {
+			if s, ok := name.(string); ok && matchesTypeName(s, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesTypeName(name string, value any) bool {
+	switch name {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return jsonTypeOf(value) == name
+	}
+}
+
+func jsonTypeOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func describeType(t any) string {
+	if list, ok := t.([]any); ok {
+		names := make([]string, 0, len(list))
+		for _, name := range list {
+			names = append(names, fmt.Sprint(name))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func compactJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,103 @@
+package mistral
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func violations(t *testing.T, err error) []SchemaViolation {
+	t.Helper()
+	var validationErr *SchemaValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a schema validation error, got %v", err)
+	}
+	return validationErr.Violations
+}
+
+func TestValidateJSON(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"required":             []string{"city", "days"},
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"city":  map[string]interface{}{"type": "string", "minLength": 2, "pattern": "^[A-Z]"},
+			"days":  map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 14},
+			"unit":  map[string]interface{}{"type": "string", "enum": []string{"celsius", "fahrenheit"}},
+			"ratio": map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 1},
+			"tags":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "maxItems": 2},
+			"note":  map[string]interface{}{"type": []string{"string", "null"}},
+		},
+	}
+
+	assert.NoError(t, ValidateJSON(schema, []byte(`{"city":"Dallas","days":3,"unit":"celsius","ratio":0.5,"tags":["a"],"note":null}`)))
+
+	err := ValidateJSON(schema, []byte(`{"city":"d","days":3.5,"unit":"kelvin","ratio":1,"tags":["a",2,"c"],"note":1,"extra":true}`))
+	assert.Equal(t, []SchemaViolation{
+		{Path: "$.city", Message: "string must be at least 2 characters long"},
+		{Path: "$.city", Message: `string does not match pattern "^[A-Z]"`},
+		{Path: "$.days", Message: "expected integer but got number"},
+		{Path: "$.extra", Message: "additional property is not allowed"},
+		{Path: "$.note", Message: "expected string or null but got number"},
+		{Path: "$.ratio", Message: "value must be < 1"},
+		{Path: "$.tags", Message: "array must have at most 2 items"},
+		{Path: "$.tags[1]", Message: "expected string but got number"},
+		{Path: "$.unit", Message: `value must be one of ["celsius","fahrenheit"]`},
+	}, violations(t, err))
+
+	err = ValidateJSON(schema, []byte(`{"days":0}`))
+	assert.Equal(t, []SchemaViolation{
+		{Path: "$.city", Message: "required property is missing"},
+		{Path: "$.days", Message: "value must be >= 1"},
+	}, violations(t, err))
+
+	err = ValidateJSON(schema, []byte(`{"city":`))
+	assert.Equal(t, "$", violations(t, err)[0].Path)
+	assert.ErrorContains(t, err, "invalid JSON")
+}
+
+func TestValidateJSONCombinators(t *testing.T) {
+	schema := map[string]interface{}{
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "integer"},
+		},
+		"oneOf": []interface{}{
+			map[string]interface{}{"const": "a"},
+			map[string]interface{}{"type": "integer"},
+		},
+		"allOf": []interface{}{true},
+	}
+	assert.NoError(t, ValidateJSON(schema, []byte(`"a"`)))
+	assert.NoError(t, ValidateJSON(schema, []byte(`2`)))
+	assert.Error(t, ValidateJSON(schema, []byte(`"b"`)))
+	assert.Error(t, ValidateJSON(schema, []byte(`true`)))
+	assert.Error(t, ValidateJSON(false, []byte(`1`)))
+}
+
+func TestValidateGeneratedSchema(t *testing.T) {
+	schema, err := JSONSchemaFor[schemaParams]()
+	assert.NoError(t, err)
+
+	valid := `{"id":"1","address":{"city":"Dallas","state":"TX"},"days":3,"tags":[],"since":"2024-01-01T00:00:00Z","count":1,"pair":[true,false]}`
+	assert.NoError(t, ValidateJSON(schema, []byte(valid)))
+
+	err = ValidateJSON(schema, []byte(`{"id":"1","address":{"city":"Dallas","state":"Texas"},"days":30,"tags":[],"since":"x","count":-1,"pair":[true]}`))
+	assert.Equal(t, []SchemaViolation{
+		{Path: "$.address.state", Message: `string does not match pattern "^[A-Z]{2}$"`},
+		{Path: "$.count", Message: "value must be >= 0"},
+		{Path: "$.days", Message: "value must be <= 14"},
+		{Path: "$.pair", Message: "array must have at least 2 items"},
+	}, violations(t, err))
+}
+
+func TestValidateToolArguments(t *testing.T) {
+	tool, err := NewFunctionTool[weatherArgs]("get_weather", "Retrieve the weather")
+	assert.NoError(t, err)
+
+	assert.NoError(t, ValidateToolArguments(tool.Function, FunctionCall{Name: "get_weather", Arguments: `{"city":"Dallas","state":"TX"}`}))
+	assert.Error(t, ValidateToolArguments(tool.Function, FunctionCall{Name: "get_weather", Arguments: `{"city":"Dallas"}`}))
+	assert.Error(t, ValidateToolArguments(tool.Function, FunctionCall{Name: "get_weather", Arguments: ``}))
+	assert.NoError(t, ValidateToolArguments(Function{Name: "ping"}, FunctionCall{Name: "ping", Arguments: `not json`}))
+}
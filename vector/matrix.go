@@ -0,0 +1,61 @@
+package vector
+
+import (
+	"fmt"
+
+	"github.com/gage-technologies/mistral-go"
+)
+
+// Vectors returns the float32 values of the embeddings of res, in response order.
+func Vectors(res *mistral.EmbeddingResponse) ([][]float32, error) {
+	vectors := make([][]float32, len(res.Data))
+	for i := range res.Data {
+		embedding := &res.Data[i]
+		if embedding.Dtype() != mistral.EmbeddingDtypeFloat {
+			return nil, fmt.Errorf("embedding %d has data type %s, not float", embedding.Index, embedding.Dtype())
+		}
+		vectors[i] = embedding.Float32()
+	}
+	return vectors, nil
+}
+
+// SimilarityMatrix returns the cosine similarity of every embedding of a to every embedding of b: the value at
+// [i][j] compares a.Data[i] with b.Data[j]. Both responses must hold float embeddings of the same dimension.
+func SimilarityMatrix(a, b *mistral.EmbeddingResponse) ([][]float32, error) {
+	rows, err := normalizedVectors(a)
+	if err != nil {
+		return nil, err
+	}
+	cols, err := normalizedVectors(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 && len(cols) > 0 && len(rows[0]) != len(cols[0]) {
+		return nil, fmt.Errorf("dimension mismatch: %d and %d", len(rows[0]), len(cols[0]))
+	}
+
+	matrix := make([][]float32, len(rows))
+	for i, row := range rows {
+		matrix[i] = make([]float32, len(cols))
+		for j, col := range cols {
+			matrix[i][j] = Dot(row, col)
+		}
+	}
+	return matrix, nil
+}
+
+// normalizedVectors returns unit length copies of the embeddings of res, so their dot products are cosine
+// similarities, and checks that they all have the same dimension.
+func normalizedVectors(res *mistral.EmbeddingResponse) ([][]float32, error) {
+	vectors, err := Vectors(res)
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range vectors {
+		if len(v) != len(vectors[0]) {
+			return nil, fmt.Errorf("dimension mismatch: %d and %d", len(vectors[0]), len(v))
+		}
+		vectors[i] = Normalized(v)
+	}
+	return vectors, nil
+}
@@ -0,0 +1,71 @@
+package vector
+
+import (
+	"testing"
+
+	"github.com/gage-technologies/mistral-go"
+	"github.com/gage-technologies/mistral-go/mistraltest"
+	"github.com/stretchr/testify/assert"
+)
+
+func embeddingResponse(embeddings ...[]float64) *mistral.EmbeddingResponse {
+	res := &mistral.EmbeddingResponse{}
+	for i, embedding := range embeddings {
+		res.Data = append(res.Data, mistral.EmbeddingObject{Object: "embedding", Embedding: embedding, Index: i})
+	}
+	return res
+}
+
+func TestSimilarityMatrix(t *testing.T) {
+	a := embeddingResponse([]float64{1, 0}, []float64{0, 2})
+	b := embeddingResponse([]float64{3, 0}, []float64{1, 1}, []float64{0, -1})
+
+	matrix, err := SimilarityMatrix(a, b)
+	assert.NoError(t, err)
+	want := [][]float32{{1, 0.7071, 0}, {0, 0.7071, -1}}
+	if assert.Len(t, matrix, 2) {
+		for i := range want {
+			assert.InDeltaSlice(t, want[i], matrix[i], 1e-4)
+		}
+	}
+	// The responses are not modified.
+	assert.Equal(t, []float64{0, 2}, a.Data[1].Embedding)
+
+	_, err = SimilarityMatrix(a, embeddingResponse([]float64{1, 2, 3}))
+	assert.EqualError(t, err, "dimension mismatch: 2 and 3")
+	_, err = SimilarityMatrix(embeddingResponse([]float64{1}, []float64{1, 2}), b)
+	assert.EqualError(t, err, "dimension mismatch: 1 and 2")
+
+	matrix, err = SimilarityMatrix(a, embeddingResponse())
+	assert.NoError(t, err)
+	assert.Equal(t, [][]float32{{}, {}}, matrix)
+}
+
+func TestSimilarityMatrixOfResponses(t *testing.T) {
+	srv := mistraltest.NewServer(t)
+	client := srv.Client()
+	documents, err := client.Embeddings("mistral-embed", []string{"apple", "banana"})
+	assert.NoError(t, err)
+	queries, err := client.Embeddings("mistral-embed", []string{"banana"})
+	assert.NoError(t, err)
+
+	// The fake server returns equal embeddings for equal inputs.
+	matrix, err := SimilarityMatrix(queries, documents)
+	assert.NoError(t, err)
+	if assert.Len(t, matrix, 1) && assert.Len(t, matrix[0], 2) {
+		assert.InDelta(t, 1, matrix[0][1], 1e-5)
+		assert.Less(t, matrix[0][0], float32(0.5))
+	}
+}
+
+func TestVectorsRejectsIntegerEmbeddings(t *testing.T) {
+	srv := mistraltest.NewServer(t)
+	srv.Enqueue(mistraltest.PathEmbeddings, mistraltest.EmbeddingsResponse([]float64{1, -2}))
+
+	res, err := srv.Client().CreateEmbeddings(&mistral.EmbeddingsRequestParams{Model: "codestral-embed", Input: []string{"a"}, OutputDtype: mistral.EmbeddingDtypeInt8})
+	assert.NoError(t, err)
+	_, err = Vectors(res)
+	assert.EqualError(t, err, "embedding 0 has data type int8, not float")
+	_, err = SimilarityMatrix(res, res)
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package vector
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Match is a candidate found by TopK.
+type Match struct {
+	Index int     // The position of the candidate.
+	Score float32 // The similarity of the candidate to the query.
+}
+
+// Similarity scores how similar two vectors are; higher is more similar. Dot and Cosine are similarities.
+type Similarity func(a, b []float32) float32
+
+// NegativeEuclidean is a Similarity that ranks vectors by increasing euclidean distance.
+func NegativeEuclidean(a, b []float32) float32 {
+	return -SquaredEuclidean(a, b)
+}
+
+// TopK returns the k candidates most similar to query, most similar first, with ties broken by lower index. It
+// returns every candidate if there are fewer than k. A nil similarity uses Cosine; use Dot for normalized vectors,
+// which ranks the same and is cheaper.
+func TopK(query []float32, candidates [][]float32, k int, similarity Similarity) []Match {
+	if similarity == nil {
+		similarity = Cosine
+	}
+	if k <= 0 {
+		return nil
+	}
+	if k > len(candidates) {
+		k = len(candidates)
+	}
+
+	// A min-heap of the best matches so far: its root is the worst of them, replaced by any better candidate.
+	h := make(matchHeap, 0, k)
+	for i, candidate := range candidates {
+		m := Match{Index: i, Score: similarity(query, candidate)}
+		if len(h) < k {
+			heap.Push(&h, m)
+		} else if h.less(h[0], m) {
+			h[0] = m
+			heap.Fix(&h, 0)
+		}
+	}
+
+	matches := []Match(h)
+	sort.Slice(matches, func(i, j int) bool { return h.less(matches[j], matches[i]) })
+	return matches
+}
+
+// matchHeap orders matches from worst to best.
+type matchHeap []Match
+
+// less reports whether a is a worse match than b.
+func (h matchHeap) less(a, b Match) bool {
+	if a.Score != b.Score {
+		return a.Score < b.Score
+	}
+	return a.Index > b.Index
+}
+
+func (h matchHeap) Len() int           { return len(h) }
+func (h matchHeap) Less(i, j int) bool { return h.less(h[i], h[j]) }
+func (h matchHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *matchHeap) Push(x any)        { *h = append(*h, x.(Match)) }
+
+func (h *matchHeap) Pop() any {
+	old := *h
+	m := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return m
+}
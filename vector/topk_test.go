@@ -0,0 +1,51 @@
+package vector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopK(t *testing.T) {
+	candidates := [][]float32{
+		{1, 0},
+		{0, 1},
+		{1, 1},
+		{-1, 0},
+		{2, 0},
+	}
+	query := []float32{1, 0}
+
+	// Candidates 0 and 4 point the same way and tie, so the lower index comes first.
+	matches := TopK(query, candidates, 3, nil)
+	if assert.Len(t, matches, 3) {
+		assert.Equal(t, []int{0, 4, 2}, matchIndexes(matches))
+		assert.InDelta(t, 1, matches[0].Score, 1e-6)
+		assert.InDelta(t, 0.7071, matches[2].Score, 1e-4)
+	}
+
+	assert.Equal(t, []int{4, 0, 2}, matchIndexes(TopK(query, candidates, 3, Dot)))
+	assert.Equal(t, []int{0, 2, 4, 1, 3}, matchIndexes(TopK(query, candidates, 10, NegativeEuclidean)))
+	assert.Empty(t, TopK(query, candidates, 0, nil))
+	assert.Empty(t, TopK(query, nil, 3, nil))
+}
+
+func TestTopKMatchesSort(t *testing.T) {
+	candidates := make([][]float32, 100)
+	for i := range candidates {
+		candidates[i] = []float32{float32((i * 37) % 100)}
+	}
+	matches := TopK([]float32{1}, candidates, 5, Dot)
+	assert.Equal(t, []float32{99, 98, 97, 96, 95}, []float32{matches[0].Score, matches[1].Score, matches[2].Score, matches[3].Score, matches[4].Score})
+	for _, m := range matches {
+		assert.Equal(t, m.Score, candidates[m.Index][0])
+	}
+}
+
+func matchIndexes(matches []Match) []int {
+	indexes := make([]int, len(matches))
+	for i, m := range matches {
+		indexes[i] = m.Index
+	}
+	return indexes
+}
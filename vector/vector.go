@@ -0,0 +1,136 @@
+// Package vector provides vector math for embeddings: dot products, cosine similarity, euclidean distance,
+// normalization, top-k search and similarity matrices.
+//
+// The functions work on float32 slices, the precision embeddings are computed in, and are written as unrolled loops
+// over independent accumulators that the compiler can keep in registers and vectorize. Functions taking two vectors
+// panic if their lengths differ.
+package vector
+
+import "math"
+
+// Dot returns the dot product of a and b.
+func Dot(a, b []float32) float32 {
+	checkLengths(a, b)
+	b = b[:len(a)]
+
+	var s0, s1, s2, s3 float32
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		s0 += a[i] * b[i]
+		s1 += a[i+1] * b[i+1]
+		s2 += a[i+2] * b[i+2]
+		s3 += a[i+3] * b[i+3]
+	}
+	for ; i < len(a); i++ {
+		s0 += a[i] * b[i]
+	}
+	return (s0 + s1) + (s2 + s3)
+}
+
+// Norm returns the euclidean (L2) norm of v.
+func Norm(v []float32) float32 {
+	return float32(math.Sqrt(float64(Dot(v, v))))
+}
+
+// Cosine returns the cosine similarity of a and b, between -1 and 1. It returns 0 if either vector is zero.
+func Cosine(a, b []float32) float32 {
+	checkLengths(a, b)
+	b = b[:len(a)]
+
+	var dot0, dot1, aa0, aa1, bb0, bb1 float32
+	i := 0
+	for ; i+2 <= len(a); i += 2 {
+		dot0 += a[i] * b[i]
+		dot1 += a[i+1] * b[i+1]
+		aa0 += a[i] * a[i]
+		aa1 += a[i+1] * a[i+1]
+		bb0 += b[i] * b[i]
+		bb1 += b[i+1] * b[i+1]
+	}
+	for ; i < len(a); i++ {
+		dot0 += a[i] * b[i]
+		aa0 += a[i] * a[i]
+		bb0 += b[i] * b[i]
+	}
+
+	norms := math.Sqrt(float64(aa0+aa1) * float64(bb0+bb1))
+	if norms == 0 {
+		return 0
+	}
+	return float32(float64(dot0+dot1) / norms)
+}
+
+// SquaredEuclidean returns the squared euclidean distance between a and b. It ranks vectors like Euclidean without
+// the square root.
+func SquaredEuclidean(a, b []float32) float32 {
+	checkLengths(a, b)
+	b = b[:len(a)]
+
+	var s0, s1, s2, s3 float32
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		d0 := a[i] - b[i]
+		d1 := a[i+1] - b[i+1]
+		d2 := a[i+2] - b[i+2]
+		d3 := a[i+3] - b[i+3]
+		s0 += d0 * d0
+		s1 += d1 * d1
+		s2 += d2 * d2
+		s3 += d3 * d3
+	}
+	for ; i < len(a); i++ {
+		d := a[i] - b[i]
+		s0 += d * d
+	}
+	return (s0 + s1) + (s2 + s3)
+}
+
+// Euclidean returns the euclidean distance between a and b.
+func Euclidean(a, b []float32) float32 {
+	return float32(math.Sqrt(float64(SquaredEuclidean(a, b))))
+}
+
+// Normalize scales v in place to unit length and returns it. A zero vector is left unchanged. The dot product of
+// normalized vectors is their cosine similarity.
+func Normalize(v []float32) []float32 {
+	norm := Norm(v)
+	if norm == 0 {
+		return v
+	}
+	Scale(v, 1/norm)
+	return v
+}
+
+// Normalized returns a unit length copy of v.
+func Normalized(v []float32) []float32 {
+	return Normalize(append([]float32(nil), v...))
+}
+
+// Scale multiplies every value of v by s in place.
+func Scale(v []float32, s float32) {
+	i := 0
+	for ; i+4 <= len(v); i += 4 {
+		v[i] *= s
+		v[i+1] *= s
+		v[i+2] *= s
+		v[i+3] *= s
+	}
+	for ; i < len(v); i++ {
+		v[i] *= s
+	}
+}
+
+// ToFloat32 converts float64 values, such as EmbeddingObject.Embedding, to float32.
+func ToFloat32(v []float64) []float32 {
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = float32(x)
+	}
+	return out
+}
+
+func checkLengths(a, b []float32) {
+	if len(a) != len(b) {
+		panic("vector: length mismatch")
+	}
+}
@@ -0,0 +1,81 @@
+package vector
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// naiveDot is the reference for the unrolled loops.
+func naiveDot(a, b []float32) float64 {
+	var s float64
+	for i := range a {
+		s += float64(a[i]) * float64(b[i])
+	}
+	return s
+}
+
+func testVectors(n int) ([]float32, []float32) {
+	a := make([]float32, n)
+	b := make([]float32, n)
+	for i := range a {
+		a[i] = float32(i%7) - 3
+		b[i] = float32(i%5)*0.5 + 1
+	}
+	return a, b
+}
+
+func TestDot(t *testing.T) {
+	// Lengths around the unrolled block size exercise the remainder loops.
+	for n := 0; n <= 9; n++ {
+		a, b := testVectors(n)
+		assert.InDelta(t, naiveDot(a, b), Dot(a, b), 1e-4, "length %d", n)
+	}
+	assert.Panics(t, func() { Dot([]float32{1}, []float32{1, 2}) })
+}
+
+func TestCosine(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		a, b := testVectors(n)
+		want := naiveDot(a, b) / math.Sqrt(naiveDot(a, a)*naiveDot(b, b))
+		if naiveDot(a, a) == 0 {
+			want = 0
+		}
+		assert.InDelta(t, want, Cosine(a, b), 1e-5, "length %d", n)
+	}
+	assert.InDelta(t, 1, Cosine([]float32{1, 2, 3}, []float32{2, 4, 6}), 1e-6)
+	assert.InDelta(t, -1, Cosine([]float32{1, 0}, []float32{-3, 0}), 1e-6)
+	assert.Equal(t, float32(0), Cosine([]float32{0, 0}, []float32{1, 1}))
+	assert.Panics(t, func() { Cosine([]float32{1}, nil) })
+}
+
+func TestEuclidean(t *testing.T) {
+	assert.Equal(t, float32(5), Euclidean([]float32{0, 0}, []float32{3, 4}))
+	assert.Equal(t, float32(25), SquaredEuclidean([]float32{0, 0}, []float32{3, 4}))
+	a, b := testVectors(9)
+	var want float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		want += d * d
+	}
+	assert.InDelta(t, want, SquaredEuclidean(a, b), 1e-4)
+	assert.Panics(t, func() { Euclidean(nil, []float32{1}) })
+}
+
+func TestNormalize(t *testing.T) {
+	v := []float32{3, 0, 4, 0, 0}
+	assert.Equal(t, []float32{0.6, 0, 0.8, 0, 0}, Normalize(v))
+	assert.Equal(t, float32(0.6), v[0])
+	assert.InDelta(t, 1, Norm(v), 1e-6)
+
+	w := []float32{0, 5}
+	assert.Equal(t, []float32{0, 1}, Normalized(w))
+	assert.Equal(t, []float32{0, 5}, w)
+
+	assert.Equal(t, []float32{0, 0}, Normalize([]float32{0, 0}))
+}
+
+func TestToFloat32(t *testing.T) {
+	assert.Equal(t, []float32{0.5, -1}, ToFloat32([]float64{0.5, -1}))
+}